@@ -0,0 +1,374 @@
+package orderedmap
+
+import (
+	"container/list"
+	"reflect"
+	"sync/atomic"
+)
+
+// EntryOverhead is the approximate number of bytes used by the map for each
+// entry on a 64-bit platform, not including the key and value themselves. It
+// covers the list element (40 bytes), the internal element holding the key,
+// value, expiry and other bookkeeping (64 bytes) and the hash map slot (around
+// 32 bytes).
+const EntryOverhead = 136
+
+// NewOrderedMapWithByteCapacity creates a map that evicts its oldest entries
+// whenever their total size is greater than maxBytes. The size of each entry
+// is sizer(key, value) plus EntryOverhead, the same as EstimatedSize. The total
+// is updated as entries are added, replaced and removed.
+//
+// Entries are evicted until the total fits, so setting an entry that is larger
+// than maxBytes on its own will evict every entry including itself.
+func NewOrderedMapWithByteCapacity(maxBytes int,
+	sizer func(key, value interface{}) int, options ...Option) *OrderedMap {
+	m := NewOrderedMap(options...)
+	m.maxSize = maxBytes
+	m.sizer = sizer
+
+	return m
+}
+
+// NewRecencyCache creates a map that holds the max most recently set distinct
+// keys, like a sliding window. Setting a key that already exists replaces its
+// value and moves it to the back, and adding a key to a full map evicts the
+// front, so the front is always the least recently set key and the back the
+// most recently set. See LeastRecent and MostRecent.
+//
+// Set and the methods that set a value in the same way, such as TrySet,
+// GetAndSet and UnmarshalJSON, move a key. Reading a key does not, unless
+// WithGetPromotesRecency(true) is also given to make it a full LRU cache. The
+// options are applied after the capacity, so WithCapacity overrides max.
+func NewRecencyCache(max int, options ...Option) *OrderedMap {
+	m := NewOrderedMap(append([]Option{WithCapacity(max)}, options...)...)
+	m.setPromotes = true
+
+	return m
+}
+
+// LeastRecent returns the key and value at the front of the map, which is the
+// least recently set key in a map created with NewRecencyCache. ok will be
+// false if the map is empty.
+func (m *OrderedMap) LeastRecent() (key, value interface{}, ok bool) {
+	front := m.Front()
+	if front == nil {
+		return nil, nil, false
+	}
+
+	return front.Key, front.Value, true
+}
+
+// MostRecent returns the key and value at the back of the map, which is the
+// most recently set key in a map created with NewRecencyCache. ok will be
+// false if the map is empty.
+func (m *OrderedMap) MostRecent() (key, value interface{}, ok bool) {
+	back := m.Back()
+	if back == nil {
+		return nil, nil, false
+	}
+
+	return back.Key, back.Value, true
+}
+
+// GetOrCompute returns the value for a key. If the key does not exist or has
+// expired, the value is computed with compute and set for the key. Setting the
+// key goes through the same capacity checks as Set, and evicted will be true
+// if any entries were evicted to make room for it.
+//
+// The lookup, compute and set all happen while holding the write lock, so
+// compute is called at most once for concurrent callers with the same key and
+// must not call other methods on the map.
+func (m *OrderedMap) GetOrCompute(key interface{},
+	compute func() interface{}) (value interface{}, evicted bool) {
+	m.Lock()
+	defer m.Unlock()
+	m.hit(key)
+	if m.getPromotes {
+		m.promote(key)
+	}
+
+	if value, ok := m.get(key); ok {
+		return value, false
+	}
+
+	value = m.mustValidValue(key, compute())
+	count := len(m.kv)
+	if m.set(key, value) {
+		count++
+	}
+
+	return value, len(m.kv) < count
+}
+
+// inserted must be called with the write lock held after a new element is
+// added. It evicts entries beyond the capacity and wakes anything waiting for
+// a key.
+func (m *OrderedMap) inserted(element *orderedMapElement) {
+	if m.sizer != nil {
+		m.size += m.sizer(element.key, element.value) + EntryOverhead
+	}
+
+	if len(m.kv) > m.peak {
+		m.peak = len(m.kv)
+	}
+
+	m.version++
+	element.version = m.version
+	if m.values != nil {
+		m.values.add(element)
+	}
+
+	if m.store != nil {
+		m.store.Set(element.key, element.value)
+	}
+
+	if m.policy != nil {
+		m.policy.OnSet(element.key)
+	}
+
+	m.evict(element)
+	m.broadcast()
+}
+
+// replace sets the value of an existing element, removing any TTL. It does
+// nothing if the map is append only. The caller must hold the write lock.
+func (m *OrderedMap) replace(element *orderedMapElement, value interface{}) {
+	if m.appendOnly {
+		return
+	}
+
+	if m.sizer != nil {
+		m.size += m.sizer(element.key, value) -
+			m.sizer(element.key, element.value)
+	}
+
+	if !sameValue(element.value, value) {
+		m.version++
+		element.version = m.version
+	}
+
+	if m.values != nil {
+		m.values.remove(element)
+	}
+
+	element.value = value
+	element.expires = 0
+	if m.values != nil {
+		m.values.add(element)
+	}
+
+	if m.store != nil {
+		m.store.Set(element.key, value)
+	}
+
+	if m.policy != nil {
+		m.policy.OnSet(element.key)
+	}
+
+	m.evict(element)
+}
+
+// remove deletes an element from the map and returns it. The caller must hold
+// the write lock.
+func (m *OrderedMap) remove(element *list.Element) *orderedMapElement {
+	e := m.ll.Remove(element).(*orderedMapElement)
+	delete(m.kv, e.key)
+	m.version++
+	if m.values != nil {
+		m.values.remove(e)
+	}
+
+	if m.store != nil {
+		m.store.Delete(e.key)
+	}
+
+	if m.policy != nil {
+		m.policy.OnDelete(e.key)
+	}
+
+	if m.sizer != nil {
+		m.size -= m.sizer(e.key, e.value) + EntryOverhead
+	}
+
+	if float64(len(m.kv)) < m.autoCompact*float64(m.peak) {
+		m.compact()
+	}
+
+	return e
+}
+
+// evict removes entries until the map is within its capacity. keep is the
+// entry that was just set, which is only evicted once nothing else is left.
+func (m *OrderedMap) evict(keep *orderedMapElement) {
+	for m.ll.Len() > 0 && m.overCapacity() {
+		e := m.remove(m.victim(keep))
+		m.recordEviction(Entry{e.key, e.value})
+		if m.onEvict != nil {
+			m.onEvict(e.key, e.value)
+		}
+	}
+}
+
+// victim returns the next element to evict other than keep. That is the
+// oldest element or, with WithLFU, the least frequently used. This is O(n)
+// with WithLFU. With WithEvictionPolicy it is the element the policy chooses.
+func (m *OrderedMap) victim(keep *orderedMapElement) *list.Element {
+	if m.policy != nil {
+		if key, ok := m.policy.Victim(); ok {
+			if e, ok := m.kv[key]; ok {
+				return e
+			}
+		}
+	}
+
+	var victim *list.Element
+	var hits uint32
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		element := e.Value.(*orderedMapElement)
+		if element == keep {
+			continue
+		}
+
+		if !m.lfu {
+			return e
+		}
+
+		if victim == nil || element.hits < hits {
+			victim, hits = e, element.hits
+		}
+	}
+
+	if victim == nil {
+		return m.ll.Front()
+	}
+
+	return victim
+}
+
+// hit counts a read of key for WithLFU and WithEvictionPolicy. The caller must
+// hold the lock, which must be the write lock with WithEvictionPolicy.
+func (m *OrderedMap) hit(key interface{}) {
+	if !m.lfu && m.policy == nil {
+		return
+	}
+
+	element, ok := m.kv[key]
+	if !ok {
+		return
+	}
+
+	if m.lfu {
+		atomic.AddUint32(&element.Value.(*orderedMapElement).hits, 1)
+	}
+
+	if m.policy != nil {
+		m.policy.OnGet(key)
+	}
+}
+
+// promote moves key to the back for WithGetPromotesRecency. The caller must
+// hold the write lock.
+func (m *OrderedMap) promote(key interface{}) {
+	element, ok := m.kv[key]
+	if !ok || m.less != nil || m.unordered || element == m.ll.Back() ||
+		element.Value.(*orderedMapElement).expired() {
+		return
+	}
+
+	m.ll.MoveToBack(element)
+	m.version++
+}
+
+// Peek returns the value for a key like Get, but the read is never counted. It
+// does not move the key with WithGetPromotesRecency or add to its Frequency
+// with WithLFU, so it does not change which key will be evicted next.
+func (m *OrderedMap) Peek(key interface{}) (interface{}, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.get(key)
+}
+
+// Frequency returns the number of times key has been read with Get or
+// GetOrDefault since it was added. Reads are only counted when the map was
+// created with WithLFU. ok will be false if the key does not exist.
+func (m *OrderedMap) Frequency(key interface{}) (count int, ok bool) {
+	m.RLock()
+	defer m.RUnlock()
+	element, ok := m.kv[key]
+	if !ok {
+		return 0, false
+	}
+
+	hits := atomic.LoadUint32(&element.Value.(*orderedMapElement).hits)
+
+	return int(hits), true
+}
+
+func (m *OrderedMap) overCapacity() bool {
+	return (m.capacity > 0 && m.ll.Len() > m.capacity) ||
+		(m.sizer != nil && m.size > m.maxSize)
+}
+
+func (m *OrderedMap) recordEviction(entry Entry) {
+	if m.maxEvicted <= 0 {
+		return
+	}
+
+	if len(m.evicted) < m.maxEvicted {
+		m.evicted = append(m.evicted, entry)
+		return
+	}
+
+	m.evicted[m.evictedNext] = entry
+	m.evictedNext = (m.evictedNext + 1) % m.maxEvicted
+}
+
+// EvictedHistory returns the most recently evicted entries, oldest first. Only
+// entries evicted because of WithCapacity are recorded, and only if the map
+// was created with WithEvictionHistory.
+func (m *OrderedMap) EvictedHistory() []Entry {
+	m.RLock()
+	defer m.RUnlock()
+	history := make([]Entry, 0, len(m.evicted))
+	history = append(history, m.evicted[m.evictedNext:]...)
+
+	return append(history, m.evicted[:m.evictedNext]...)
+}
+
+// EstimatedSize returns an estimate of the memory used by the map in bytes. It
+// is the sum of sizer for every entry, plus EntryOverhead for each entry.
+func (m *OrderedMap) EstimatedSize(sizer func(key, value interface{}) int) int {
+	m.RLock()
+	defer m.RUnlock()
+	size := 0
+	m.elements(func(e *list.Element) bool {
+		element := e.Value.(*orderedMapElement)
+		size += sizer(element.key, element.value) + EntryOverhead
+		return true
+	})
+
+	return size
+}
+
+// sameValue reports whether a and b are equal, without panicking for values
+// that cannot be compared with ==. Such values are never considered equal,
+// including structs and arrays whose interface fields hold uncomparable values,
+// which panic even though their type is comparable.
+func sameValue(a, b interface{}) (same bool) {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	typ := reflect.TypeOf(a)
+	if typ != reflect.TypeOf(b) || !typ.Comparable() {
+		return false
+	}
+
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+
+	return a == b
+}
@@ -0,0 +1,389 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCapacity(t *testing.T) {
+	t.Run("EvictsOldest", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		assert.Equal(t, []interface{}{2, 3}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("ReplacingDoesntEvict", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(1, false)
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+	})
+}
+
+func TestNewRecencyCache(t *testing.T) {
+	t.Run("SlidingWindow", func(t *testing.T) {
+		m := orderedmap.NewRecencyCache(3)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("c", 3)
+		m.Set("a", 4)
+		m.Set("d", 5)
+		assert.Equal(t, []interface{}{"c", "a", "d"}, m.Keys())
+
+		m.Get("c")
+		m.Set("e", 6)
+		assert.Equal(t, []interface{}{"a", "d", "e"}, m.Keys())
+	})
+
+	t.Run("LRU", func(t *testing.T) {
+		m := orderedmap.NewRecencyCache(2,
+			orderedmap.WithGetPromotesRecency(true))
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Get("a")
+		m.Set("c", 3)
+		assert.Equal(t, []interface{}{"a", "c"}, m.Keys())
+	})
+}
+
+func TestOrderedMap_LeastRecent(t *testing.T) {
+	m := orderedmap.NewRecencyCache(3)
+	_, _, ok := m.LeastRecent()
+	assert.False(t, ok)
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("a", 3)
+	key, value, ok := m.LeastRecent()
+	assert.True(t, ok)
+	assert.Equal(t, "b", key)
+	assert.Equal(t, 2, value)
+}
+
+func TestOrderedMap_MostRecent(t *testing.T) {
+	m := orderedmap.NewRecencyCache(3)
+	_, _, ok := m.MostRecent()
+	assert.False(t, ok)
+
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("a", 3)
+	key, value, ok := m.MostRecent()
+	assert.True(t, ok)
+	assert.Equal(t, "a", key)
+	assert.Equal(t, 3, value)
+}
+
+func TestOrderedMap_GetOrCompute(t *testing.T) {
+	t.Run("ExistingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		value, evicted := m.GetOrCompute(1, func() interface{} {
+			t.Fatal("compute should not be called")
+			return nil
+		})
+		assert.Equal(t, "a", value)
+		assert.False(t, evicted)
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		value, evicted := m.GetOrCompute(1, func() interface{} {
+			return "a"
+		})
+		assert.Equal(t, "a", value)
+		assert.False(t, evicted)
+		assert.Equal(t, []interface{}{1}, m.Keys())
+	})
+
+	t.Run("Evicts", func(t *testing.T) {
+		var evictedKeys []interface{}
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2),
+			orderedmap.WithEvictionCallback(func(key, value interface{}) {
+				evictedKeys = append(evictedKeys, key)
+			}))
+		m.Set(1, "a")
+		m.Set(2, "b")
+		value, evicted := m.GetOrCompute(3, func() interface{} {
+			return "c"
+		})
+		assert.Equal(t, "c", value)
+		assert.True(t, evicted)
+		assert.Equal(t, []interface{}{2, 3}, m.Keys())
+		assert.Equal(t, []interface{}{1}, evictedKeys)
+		assert.NoError(t, m.Validate())
+	})
+}
+
+func TestWithGetPromotesRecency(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2),
+			orderedmap.WithGetPromotesRecency(false))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Get(1)
+		m.Set(3, true)
+		assert.Equal(t, []interface{}{2, 3}, m.Keys())
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2),
+			orderedmap.WithGetPromotesRecency(true))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Get(1)
+		m.Set(3, true)
+		assert.Equal(t, []interface{}{1, 3}, m.Keys())
+		m.GetOrDefault(1, nil)
+		m.Set(4, true)
+		assert.Equal(t, []interface{}{1, 4}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("SetDoesntPromote", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithGetPromotesRecency(true))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(1, false)
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithGetPromotesRecency(true))
+		m.Set(1, true)
+		m.Set(2, true)
+		version := m.Version()
+		m.Get(2)
+		assert.Equal(t, version, m.Version())
+		m.Get(1)
+		assert.NotEqual(t, version, m.Version())
+	})
+}
+
+func TestOrderedMap_Peek(t *testing.T) {
+	t.Run("DoesntPromote", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithGetPromotesRecency(true))
+		m.Set(1, "a")
+		m.Set(2, "b")
+		value, ok := m.Peek(1)
+		assert.Equal(t, "a", value)
+		assert.True(t, ok)
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+	})
+
+	t.Run("DoesntCountFrequency", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithLFU())
+		m.Set(1, "a")
+		m.Peek(1)
+		count, _ := m.Frequency(1)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		value, ok := m.Peek(1)
+		assert.Nil(t, value)
+		assert.False(t, ok)
+	})
+}
+
+func TestOrderedMap_EvictedHistory(t *testing.T) {
+	t.Run("EmptyWithoutOption", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(1))
+		m.Set(1, true)
+		m.Set(2, true)
+		assert.Empty(t, m.EvictedHistory())
+	})
+
+	t.Run("RecordsEvictions", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithCapacity(1),
+			orderedmap.WithEvictionHistory(3),
+		)
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.Set(3, "baz")
+		assert.Equal(t, []orderedmap.Entry{
+			{Key: 1, Value: "foo"},
+			{Key: 2, Value: "bar"},
+		}, m.EvictedHistory())
+	})
+
+	t.Run("KeepsMostRecent", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithCapacity(1),
+			orderedmap.WithEvictionHistory(2),
+		)
+		for i := 1; i <= 5; i++ {
+			m.Set(i, true)
+		}
+		assert.Equal(t, []orderedmap.Entry{
+			{Key: 3, Value: true},
+			{Key: 4, Value: true},
+		}, m.EvictedHistory())
+	})
+
+	t.Run("DeleteIsNotEviction", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithCapacity(2),
+			orderedmap.WithEvictionHistory(2),
+		)
+		m.Set(1, true)
+		m.Delete(1)
+		assert.Empty(t, m.EvictedHistory())
+	})
+}
+
+func TestOrderedMap_EstimatedSize(t *testing.T) {
+	sizer := func(key, value interface{}) int {
+		return len(key.(string)) + len(value.(string))
+	}
+
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, 0, m.EstimatedSize(sizer))
+	})
+
+	t.Run("IncludesOverhead", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "bar")
+		m.Set("a", "bc")
+		assert.Equal(t, 9+2*orderedmap.EntryOverhead, m.EstimatedSize(sizer))
+	})
+}
+
+func TestNewOrderedMapWithByteCapacity(t *testing.T) {
+	sizer := func(key, value interface{}) int {
+		return len(value.(string))
+	}
+	maxBytes := 2*orderedmap.EntryOverhead + 6
+
+	t.Run("EvictsOldestUntilFits", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapWithByteCapacity(maxBytes, sizer)
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+		m.Set(3, "baz")
+		assert.Equal(t, []interface{}{2, 3}, m.Keys())
+		assert.True(t, m.EstimatedSize(sizer) <= maxBytes)
+	})
+
+	t.Run("ReplacingLargerValueEvicts", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapWithByteCapacity(maxBytes, sizer)
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.Set(2, "barbaz")
+		assert.Equal(t, []interface{}{2}, m.Keys())
+	})
+
+	t.Run("DeleteFreesSpace", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapWithByteCapacity(maxBytes, sizer)
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.Delete(1)
+		m.Set(3, "baz")
+		assert.Equal(t, []interface{}{2, 3}, m.Keys())
+	})
+
+	t.Run("FiresCallback", func(t *testing.T) {
+		var evicted []interface{}
+		m := orderedmap.NewOrderedMapWithByteCapacity(maxBytes, sizer,
+			orderedmap.WithEvictionCallback(func(key, value interface{}) {
+				evicted = append(evicted, key, value)
+			}))
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.Set(3, "bazqux")
+		assert.Equal(t, []interface{}{1, "foo", 2, "bar"}, evicted)
+		assert.Equal(t, []interface{}{3}, m.Keys())
+	})
+}
+
+func TestWithEvictionCallback(t *testing.T) {
+	var evicted []interface{}
+	m := orderedmap.NewOrderedMap(
+		orderedmap.WithCapacity(1),
+		orderedmap.WithEvictionCallback(func(key, value interface{}) {
+			evicted = append(evicted, key, value)
+		}),
+	)
+	m.Set(1, "foo")
+	m.Set(2, "bar")
+	m.Delete(2)
+	assert.Equal(t, []interface{}{1, "foo"}, evicted)
+}
+
+func TestWithLFU(t *testing.T) {
+	t.Run("EvictsLeastFrequent", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithCapacity(3),
+			orderedmap.WithLFU(),
+		)
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		m.Get(1)
+		m.Get(1)
+		m.Get(2)
+		m.GetOrDefault(3, nil)
+		m.GetOrDefault(3, nil)
+		m.Set(4, true)
+		assert.Equal(t, []interface{}{1, 3, 4}, m.Keys())
+	})
+
+	t.Run("TiesEvictOldest", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithCapacity(2),
+			orderedmap.WithLFU(),
+		)
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		assert.Equal(t, []interface{}{2, 3}, m.Keys())
+	})
+
+	t.Run("NewKeyIsKept", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithCapacity(1),
+			orderedmap.WithLFU(),
+		)
+		m.Set(1, true)
+		m.Get(1)
+		m.Set(2, true)
+		assert.Equal(t, []interface{}{2}, m.Keys())
+	})
+}
+
+func TestOrderedMap_Frequency(t *testing.T) {
+	t.Run("MissingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithLFU())
+		_, ok := m.Frequency(1)
+		assert.False(t, ok)
+	})
+
+	t.Run("CountsReads", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithLFU())
+		m.Set(1, true)
+		m.Get(1)
+		m.Get(1)
+		m.Get(2)
+		count, ok := m.Frequency(1)
+		assert.True(t, ok)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("NotCountedWithoutLFU", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Get(1)
+		count, ok := m.Frequency(1)
+		assert.True(t, ok)
+		assert.Equal(t, 0, count)
+	})
+}
@@ -0,0 +1,27 @@
+package orderedmap
+
+import "container/list"
+
+// Unlock releases the write lock. With WithCopyOnWrite it first publishes a
+// new snapshot for readers if the map was changed while the lock was held.
+func (m *OrderedMap) Unlock() {
+	if m.copyOnWrite && m.version != m.snapshotVersion {
+		entries := make([]Entry, 0, len(m.kv))
+		m.elements(func(e *list.Element) bool {
+			element := e.Value.(*orderedMapElement)
+			entries = append(entries, Entry{element.key, element.value})
+			return true
+		})
+
+		m.snapshot.Store(entries)
+		m.snapshotVersion = m.version
+	}
+
+	m.RWMutex.Unlock()
+}
+
+// entries returns the current snapshot for WithCopyOnWrite. It does not lock
+// and must not be modified.
+func (m *OrderedMap) entries() []Entry {
+	return m.snapshot.Load().([]Entry)
+}
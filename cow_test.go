@@ -0,0 +1,73 @@
+package orderedmap_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCopyOnWrite(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCopyOnWrite())
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, []interface{}{}, m.Keys())
+	})
+
+	t.Run("SeesWrites", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCopyOnWrite())
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		m.Delete(2)
+		assert.Equal(t, []interface{}{1, 3}, m.Keys())
+		assert.Equal(t, 2, m.Len())
+
+		m.Set(1, "A")
+		var values []interface{}
+		m.ForEach(func(key, value interface{}) bool {
+			values = append(values, value)
+			return true
+		})
+		assert.Equal(t, []interface{}{"A", "c"}, values)
+	})
+
+	t.Run("ForEachCanModify", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCopyOnWrite())
+		m.Set(1, "a")
+		m.Set(2, "b")
+		var keys []interface{}
+		m.ForEach(func(key, value interface{}) bool {
+			keys = append(keys, key)
+			m.Delete(key)
+			m.Set(key.(int)+10, value)
+			return true
+		})
+		assert.Equal(t, []interface{}{1, 2}, keys)
+		assert.Equal(t, []interface{}{11, 12}, m.Keys())
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCopyOnWrite())
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Set(i, true)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				keys := m.Keys()
+				for j, key := range keys {
+					assert.Equal(t, j, key)
+				}
+			}
+		}()
+		wg.Wait()
+		assert.Equal(t, 1000, m.Len())
+	})
+}
@@ -2,10 +2,19 @@ package orderedmap
 
 import "container/list"
 
+// Entry is a key and value copied out of the map.
+type Entry struct {
+	Key, Value interface{}
+}
+
 type Element struct {
 	Key, Value interface{}
 
 	element *list.Element
+
+	// small and index locate the element in a SmallOrderedMap instead.
+	small *SmallOrderedMap
+	index int
 }
 
 func newElement(e *list.Element) *Element {
@@ -24,10 +33,18 @@ func newElement(e *list.Element) *Element {
 
 // Next returns the next element, or nil if it finished.
 func (e *Element) Next() *Element {
+	if e.small != nil {
+		return e.small.elementAt(e.index + 1)
+	}
+
 	return newElement(e.element.Next())
 }
 
 // Prev returns the previous element, or nil if it finished.
 func (e *Element) Prev() *Element {
+	if e.small != nil {
+		return e.small.elementAt(e.index - 1)
+	}
+
 	return newElement(e.element.Prev())
 }
@@ -0,0 +1,46 @@
+package orderedmap
+
+import "net/http"
+
+// ServeHTTP writes the map as JSON, in the same form as MarshalJSON, so that a
+// map can be served directly, for example as a debugging endpoint:
+//
+//	http.Handle("/debug/config", m)
+//
+// The JSON is streamed with EncodeJSON so large maps are not buffered in
+// memory. An error before anything has been written is sent as a 500 Internal
+// Server Error, but an error partway through leaves the response truncated.
+// Adding ?pretty=1 to the URL indents the output, which does buffer it.
+func (m *OrderedMap) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("pretty") != "1" {
+		cw := &countingWriter{w: w}
+		err := m.EncodeJSON(cw)
+		if err != nil && cw.n == 0 {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	b, err := m.MarshalJSONIndent("", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(append(b, '\n'))
+}
+
+// countingWriter counts the bytes written to w, so that ServeHTTP knows
+// whether it can still send an error status.
+type countingWriter struct {
+	w http.ResponseWriter
+	n int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += n
+	return n, err
+}
@@ -0,0 +1,45 @@
+package orderedmap_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_ServeHTTP(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("b", 1)
+	m.Set("a", []int{2})
+
+	t.Run("Compact", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `{"b":1,"a":[2]}`, rec.Body.String())
+	})
+
+	t.Run("Pretty", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest("GET", "/?pretty=1", nil))
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "{\n  \"b\": 1,\n  \"a\": [\n    2\n  ]\n}\n",
+			rec.Body.String())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", make(chan int))
+		rec := httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest("GET", "/?pretty=1", nil))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		rec = httptest.NewRecorder()
+		m.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Contains(t, rec.Body.String(), "chan int")
+	})
+}
@@ -0,0 +1,30 @@
+package orderedmap
+
+import "sync"
+
+// internedKeys holds the shared copy of every string key interned by a map
+// created with WithKeyInterning. It is shared by all maps and never shrinks.
+var internedKeys sync.Map
+
+// intern returns the shared copy of key if the map was created with
+// WithKeyInterning and key is a string. Otherwise key is returned unchanged.
+func (m *OrderedMap) intern(key interface{}) interface{} {
+	if !m.internKeys {
+		return key
+	}
+
+	s, ok := key.(string)
+	if !ok {
+		return key
+	}
+
+	if shared, ok := internedKeys.Load(s); ok {
+		return shared
+	}
+
+	// Copy the string so that the interned key does not keep a larger
+	// buffer it was sliced from alive.
+	shared, _ := internedKeys.LoadOrStore(s, string([]byte(s)))
+
+	return shared
+}
@@ -0,0 +1,51 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+// stringData returns the address of the bytes of the first key of m.
+func stringData(m *orderedmap.OrderedMap) uintptr {
+	s := m.Keys()[0].(string)
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestWithKeyInterning(t *testing.T) {
+	t.Run("Shared", func(t *testing.T) {
+		a := orderedmap.NewOrderedMap(orderedmap.WithKeyInterning())
+		b := orderedmap.NewOrderedMap(orderedmap.WithKeyInterning())
+		a.Set(strings.Repeat("interned", 2), 1)
+		b.Set(strings.Repeat("interned", 2), 2)
+		assert.Equal(t, stringData(a), stringData(b))
+		assert.Equal(t, 2, b.GetOrDefault("internedinterned", nil))
+	})
+
+	t.Run("NotShared", func(t *testing.T) {
+		a := orderedmap.NewOrderedMap()
+		b := orderedmap.NewOrderedMap()
+		a.Set(strings.Repeat("separate", 2), 1)
+		b.Set(strings.Repeat("separate", 2), 2)
+		assert.NotEqual(t, stringData(a), stringData(b))
+	})
+
+	t.Run("UnmarshalJSON", func(t *testing.T) {
+		a := orderedmap.NewOrderedMap(orderedmap.WithKeyInterning())
+		b := orderedmap.NewOrderedMap(orderedmap.WithKeyInterning())
+		assert.NoError(t, json.Unmarshal([]byte(`{"decoded":1}`), a))
+		assert.NoError(t, json.Unmarshal([]byte(`{"decoded":2}`), b))
+		assert.Equal(t, stringData(a), stringData(b))
+	})
+
+	t.Run("NonStringKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithKeyInterning())
+		m.Set(1, "foo")
+		assert.Equal(t, []interface{}{1}, m.Keys())
+	})
+}
@@ -0,0 +1,158 @@
+package orderedmap
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+)
+
+// MaxIntOrderedMapKey is the largest key an IntOrderedMap accepts. The index
+// for a map holding it takes 128 MiB on a 64-bit machine.
+const MaxIntOrderedMapKey = 1<<24 - 1
+
+type intOrderedMapElement struct {
+	key   int
+	value interface{}
+}
+
+// IntOrderedMap is an ordered map specialized for small, dense, non-negative
+// int keys. Instead of a Go map it uses a slice indexed by key to find each
+// entry, which avoids hashing and boxing the keys. It is safe for concurrent
+// use.
+//
+// The slice grows to the largest key ever set, so memory use is proportional
+// to that key rather than the number of entries. Keys above MaxIntOrderedMapKey
+// are not accepted. Use OrderedMap for sparse or large keys.
+type IntOrderedMap struct {
+	index []*list.Element
+	ll    list.List
+	sync.RWMutex
+}
+
+// NewIntOrderedMap creates an empty IntOrderedMap.
+func NewIntOrderedMap() *IntOrderedMap {
+	return &IntOrderedMap{}
+}
+
+// element returns the list element for key, or nil if it does not exist. The
+// caller must hold the lock.
+func (m *IntOrderedMap) element(key int) *list.Element {
+	if key < 0 || key >= len(m.index) {
+		return nil
+	}
+
+	return m.index[key]
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *IntOrderedMap) Get(key int) (interface{}, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	if element := m.element(key); element != nil {
+		return element.Value.(*intOrderedMapElement).value, true
+	}
+
+	return nil, false
+}
+
+// Has returns true if the key exists in the map.
+func (m *IntOrderedMap) Has(key int) bool {
+	m.RLock()
+	defer m.RUnlock()
+	return m.element(key) != nil
+}
+
+// Set will set (or replace) a value for a key. If the key was new, then true
+// will be returned. The returned value will be false if the value was replaced
+// (even if the value was the same). It panics if key is negative or greater
+// than MaxIntOrderedMapKey.
+func (m *IntOrderedMap) Set(key int, value interface{}) bool {
+	if key < 0 {
+		panic(fmt.Sprintf("orderedmap: negative key %d", key))
+	}
+
+	if key > MaxIntOrderedMapKey {
+		panic(fmt.Sprintf("orderedmap: key %d is greater than %d", key,
+			MaxIntOrderedMapKey))
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if element := m.element(key); element != nil {
+		element.Value.(*intOrderedMapElement).value = value
+		return false
+	}
+
+	if key >= cap(m.index) {
+		size := 2 * cap(m.index)
+		if size < key+1 {
+			size = key + 1
+		}
+
+		if size > MaxIntOrderedMapKey+1 {
+			size = MaxIntOrderedMapKey + 1
+		}
+
+		index := make([]*list.Element, key+1, size)
+		copy(index, m.index)
+		m.index = index
+	} else if key >= len(m.index) {
+		m.index = m.index[:key+1]
+	}
+
+	element := &intOrderedMapElement{key: key, value: value}
+	m.index[key] = m.ll.PushBack(element)
+
+	return true
+}
+
+// Len returns the number of elements in the map.
+func (m *IntOrderedMap) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return m.ll.Len()
+}
+
+// Keys returns all of the keys in the order they were inserted.
+func (m *IntOrderedMap) Keys() (keys []int) {
+	m.RLock()
+	defer m.RUnlock()
+	keys = make([]int, 0, m.ll.Len())
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*intOrderedMapElement).key)
+	}
+
+	return keys
+}
+
+// Delete will remove a key from the map. It will return true if the key was
+// removed (the key did exist).
+func (m *IntOrderedMap) Delete(key int) (didDelete bool) {
+	m.Lock()
+	defer m.Unlock()
+	element := m.element(key)
+	if element == nil {
+		return false
+	}
+
+	m.ll.Remove(element)
+	m.index[key] = nil
+
+	return true
+}
+
+// ForEach calls fn for every key and value from oldest to newest. Iteration
+// stops if fn returns false.
+//
+// The map is read locked while iterating so fn must not modify the map.
+func (m *IntOrderedMap) ForEach(fn func(key int, value interface{}) bool) {
+	m.RLock()
+	defer m.RUnlock()
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		element := e.Value.(*intOrderedMapElement)
+		if !fn(element.key, element.value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntOrderedMap(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		m := orderedmap.NewIntOrderedMap()
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, []int{}, m.Keys())
+		value, ok := m.Get(3)
+		assert.Nil(t, value)
+		assert.False(t, ok)
+		assert.False(t, m.Has(-1))
+		assert.False(t, m.Delete(3))
+	})
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		m := orderedmap.NewIntOrderedMap()
+		assert.True(t, m.Set(5, "a"))
+		assert.True(t, m.Set(0, "b"))
+		assert.True(t, m.Set(100, "c"))
+		assert.False(t, m.Set(5, "d"))
+		value, ok := m.Get(5)
+		assert.Equal(t, "d", value)
+		assert.True(t, ok)
+		assert.True(t, m.Has(100))
+		assert.False(t, m.Has(99))
+		assert.Equal(t, []int{5, 0, 100}, m.Keys())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		m := orderedmap.NewIntOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		assert.True(t, m.Delete(2))
+		assert.False(t, m.Has(2))
+		assert.Equal(t, []int{1, 3}, m.Keys())
+		m.Set(2, "b")
+		assert.Equal(t, []int{1, 3, 2}, m.Keys())
+	})
+
+	t.Run("ForEach", func(t *testing.T) {
+		m := orderedmap.NewIntOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		var keys []int
+		m.ForEach(func(key int, value interface{}) bool {
+			keys = append(keys, key)
+			return key != 2
+		})
+		assert.Equal(t, []int{1, 2}, keys)
+	})
+
+	t.Run("NegativeKey", func(t *testing.T) {
+		m := orderedmap.NewIntOrderedMap()
+		assert.Panics(t, func() {
+			m.Set(-1, "a")
+		})
+	})
+
+	t.Run("KeyTooLarge", func(t *testing.T) {
+		m := orderedmap.NewIntOrderedMap()
+		maxInt := int(^uint(0) >> 1)
+		assert.PanicsWithValue(t, fmt.Sprintf(
+			"orderedmap: key %d is greater than %d", maxInt,
+			orderedmap.MaxIntOrderedMapKey), func() {
+			m.Set(maxInt, "a")
+		})
+		assert.Panics(t, func() {
+			m.Set(orderedmap.MaxIntOrderedMapKey+1, "a")
+		})
+		assert.Equal(t, 0, m.Len())
+
+		assert.True(t, m.Set(1000, "a"))
+		assert.True(t, m.Set(1, "b"))
+		assert.True(t, m.Set(1500, "c"))
+		assert.Equal(t, []int{1000, 1, 1500}, m.Keys())
+	})
+}
+
+func BenchmarkIntOrderedMap(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		b.Run(fmt.Sprintf("IntOrderedMap/Set%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := orderedmap.NewIntOrderedMap()
+				for j := 0; j < n; j++ {
+					m.Set(j, true)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("OrderedMap/Set%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := orderedmap.NewOrderedMap()
+				for j := 0; j < n; j++ {
+					m.Set(j, true)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("IntOrderedMap/Get%d", n), func(b *testing.B) {
+			m := orderedmap.NewIntOrderedMap()
+			for j := 0; j < n; j++ {
+				m.Set(j, true)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Get(i % n)
+			}
+		})
+
+		b.Run(fmt.Sprintf("OrderedMap/Get%d", n), func(b *testing.B) {
+			m := orderedmap.NewOrderedMap()
+			for j := 0; j < n; j++ {
+				m.Set(j, true)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Get(i % n)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+//go:build go1.23
+// +build go1.23
+
+package orderedmap
+
+import "iter"
+
+// All returns an iterator over the keys and values from oldest to newest, for
+// use with range:
+//
+//	for key, value := range m.All() {
+//		fmt.Println(key, value)
+//	}
+//
+// Like iterating with Front and Next, the map is not locked while the loop
+// body runs, so changing the map during iteration may produce unexpected
+// behavior.
+func (m *OrderedMap) All() iter.Seq2[interface{}, interface{}] {
+	return func(yield func(key, value interface{}) bool) {
+		for el := m.Front(); el != nil; el = el.Next() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward is like All but iterates from newest to oldest.
+func (m *OrderedMap) Backward() iter.Seq2[interface{}, interface{}] {
+	return func(yield func(key, value interface{}) bool) {
+		for el := m.Back(); el != nil; el = el.Prev() {
+			if !yield(el.Key, el.Value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+//go:build go1.23
+// +build go1.23
+
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_All(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		for range m.All() {
+			t.Fatal("unexpected element")
+		}
+	})
+
+	t.Run("InOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.Set(3, "baz")
+
+		var results []interface{}
+		for key, value := range m.All() {
+			results = append(results, key, value)
+		}
+
+		assert.Equal(t, []interface{}{1, "foo", 2, "bar", 3, "baz"}, results)
+	})
+
+	t.Run("Break", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+
+		var results []interface{}
+		for key := range m.All() {
+			results = append(results, key)
+			break
+		}
+
+		assert.Equal(t, []interface{}{1}, results)
+	})
+}
+
+func TestOrderedMap_Backward(t *testing.T) {
+	t.Run("InReverseOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.Set(3, "baz")
+
+		var results []interface{}
+		for key, value := range m.Backward() {
+			results = append(results, key, value)
+		}
+
+		assert.Equal(t, []interface{}{3, "baz", 2, "bar", 1, "foo"}, results)
+	})
+
+	t.Run("Break", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+
+		var results []interface{}
+		for key := range m.Backward() {
+			results = append(results, key)
+			break
+		}
+
+		assert.Equal(t, []interface{}{2}, results)
+	})
+}
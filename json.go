@@ -0,0 +1,731 @@
+package orderedmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidPairCount is returned when decoding a [key, value] pair that does
+// not have exactly two elements, or data in the older format that has a key
+// without a value.
+var ErrInvalidPairCount = errors.New("invalid data, key-value doesn't match")
+
+// ErrDuplicateKey is returned when decoding data that has the same key more
+// than once into a map created with WithUniqueJSONKeys.
+var ErrDuplicateKey = errors.New("invalid data, duplicate key")
+
+// ErrTrailingData is returned when decoding data that has more input after
+// the map, such as a second JSON value.
+var ErrTrailingData = errors.New("invalid data, unexpected data after map")
+
+// DecodeError is returned by UnmarshalJSON when data cannot be decoded. Err
+// is the underlying error, such as a *json.SyntaxError, ErrInvalidPairCount,
+// ErrDuplicateKey or ErrTrailingData, and can be checked with errors.Is and
+// errors.As.
+type DecodeError struct {
+	// Offset is the number of bytes of data read when the error was found,
+	// or -1 if it is not known.
+	Offset int64
+
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Offset < 0 {
+		return e.Err.Error()
+	}
+
+	return fmt.Sprintf("%v (at offset %d)", e.Err, e.Offset)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError wraps an error from decoding dec in a DecodeError.
+func decodeError(dec *json.Decoder, err error) error {
+	offset := inputOffset(dec)
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		offset = syntaxErr.Offset
+	}
+
+	return &DecodeError{Offset: offset, Err: err}
+}
+
+// checkEOF returns a DecodeError if dec has any input left other than
+// whitespace.
+func checkEOF(dec *json.Decoder) error {
+	_, err := dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+
+	if err == nil {
+		err = ErrTrailingData
+	}
+
+	return decodeError(dec, err)
+}
+
+// MarshalJSON encodes the map as JSON with the keys in insertion order.
+//
+// If every key is a string or implements encoding.TextMarshaler the map is
+// encoded as a JSON object, using the result of MarshalText as the name, like
+// encoding/json does for Go maps. Otherwise, or if two keys would have the same
+// name, it is encoded as an array of [key, value] pairs, where each key is
+// encoded with json.Marshal like any other value. Keys that are structs must be
+// marshalable for this to work, and must be comparable to be decoded again (see
+// WithJSONKeyType).
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf = new(bytes.Buffer)
+	err := m.EncodeJSON(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeJSON writes the same JSON produced by MarshalJSON to w. Each entry is
+// encoded straight into a buffered writer rather than building up the whole
+// document in memory first, which reduces peak memory for large maps.
+func (m *OrderedMap) EncodeJSON(w io.Writer) error {
+	encode := encodeValue
+	if m.typedJSON {
+		encode = encodeTypedValue
+	}
+
+	var buf = bufio.NewWriter(w)
+	err := m.encodeJSON(buf, encode)
+	if err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+// encodeJSON writes the map as a JSON object or array of pairs, encoding each
+// value with encode.
+func (m *OrderedMap) encodeJSON(w *bufio.Writer,
+	encode func(*bufio.Writer, interface{}) error) error {
+	keys, values := m.KeysValues()
+
+	return encodeKeysValues(w, keys, values, encode)
+}
+
+// encodeKeysValues writes keys and values as a JSON object, or as an array of
+// pairs if the keys cannot all be used as names.
+func encodeKeysValues(w *bufio.Writer, keys, values []interface{},
+	encode func(*bufio.Writer, interface{}) error) error {
+	names, ok, err := objectKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		return encodeObject(w, names, values, encode)
+	}
+
+	return encodePairs(w, keys, values, encode)
+}
+
+// MarshalJSONIndent is like MarshalJSON but applies indentation in the same way
+// as json.MarshalIndent. MarshalJSON always produces compact JSON.
+func (m *OrderedMap) MarshalJSONIndent(prefix, indent string) ([]byte, error) {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = json.Indent(&buf, b, prefix, indent)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// MarshalJSONStringKeys encodes the map as a JSON object, converting each key
+// to a string with fmt.Sprint. This is lossy, since the key types are lost and
+// different keys may convert to the same string. When that happens the last
+// value wins, at the position of the first key. It is intended for logging and
+// other output for people rather than data that will be decoded again.
+func (m *OrderedMap) MarshalJSONStringKeys() ([]byte, error) {
+	keys, values := m.KeysValues()
+	var stringKeys []interface{}
+	var stringValues []interface{}
+	seen := make(map[string]int, len(keys))
+	for i, key := range keys {
+		s := fmt.Sprint(key)
+		if j, ok := seen[s]; ok {
+			stringValues[j] = values[i]
+			continue
+		}
+
+		seen[s] = len(stringKeys)
+		stringKeys = append(stringKeys, s)
+		stringValues = append(stringValues, values[i])
+	}
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	err := encodeObject(w, stringKeys, stringValues, encodeValue)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// objectKeys returns the names to use for keys in a JSON object. ok will be
+// false if any key is not a string or encoding.TextMarshaler, or if two keys
+// have the same name.
+func objectKeys(keys []interface{}) (
+	names []interface{}, ok bool, err error) {
+	names = make([]interface{}, len(keys))
+	seen := make(map[string]struct{}, len(keys))
+	for i, key := range keys {
+		var name string
+		switch key := key.(type) {
+		case string:
+			name = key
+
+		case encoding.TextMarshaler:
+			text, err := key.MarshalText()
+			if err != nil {
+				return nil, false, err
+			}
+
+			name = string(text)
+
+		default:
+			return nil, false, nil
+		}
+
+		if _, ok := seen[name]; ok {
+			return nil, false, nil
+		}
+
+		seen[name] = struct{}{}
+		names[i] = name
+	}
+
+	return names, true, nil
+}
+
+func encodeObject(w *bufio.Writer, keys, values []interface{},
+	encode func(*bufio.Writer, interface{}) error) error {
+	w.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+
+		err := encodeValue(w, key)
+		if err != nil {
+			return err
+		}
+
+		w.WriteByte(':')
+		err = encode(w, values[i])
+		if err != nil {
+			return err
+		}
+	}
+	w.WriteByte('}')
+
+	return nil
+}
+
+func encodePairs(w *bufio.Writer, keys, values []interface{},
+	encode func(*bufio.Writer, interface{}) error) error {
+	w.WriteByte('[')
+	for i, key := range keys {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+
+		w.WriteByte('[')
+		err := encodeValue(w, key)
+		if err != nil {
+			return err
+		}
+
+		w.WriteByte(',')
+		err = encode(w, values[i])
+		if err != nil {
+			return err
+		}
+		w.WriteByte(']')
+	}
+	w.WriteByte(']')
+
+	return nil
+}
+
+func encodeValue(w *bufio.Writer, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// UnmarshalJSON adds the keys and values from data, in order, to the map. Keys
+// already in the map are kept, and keep their positions if they are also in
+// data, unless the map was created with WithUnmarshalReset.
+//
+// data may be a JSON object or an array of [key, value] pairs as produced by
+// MarshalJSON. Values are decoded in the same way as json.Unmarshal into an
+// interface{}, except that JSON objects are decoded into an *OrderedMap so that
+// nested maps keep their order. Nested maps with non-string keys are encoded as
+// arrays of pairs, so they are decoded as arrays rather than maps.
+//
+// Keys are added in the order they appear in data, not the order of a Go map.
+// If a key appears more than once the last value wins, but the key keeps the
+// position where it first appeared, unless the map was created with
+// WithUniqueJSONKeys. Keys from an array of pairs are decoded
+// like values unless a key type has been set with WithJSONKeyType. If the key
+// type implements encoding.TextUnmarshaler, the names of an object are decoded
+// with UnmarshalText.
+//
+// Data produced by older versions of this package (a base64 encoded string)
+// can also be decoded.
+//
+// Nothing is added to the map if data cannot be decoded or has anything other
+// than whitespace after the map. Decoding errors are returned as a
+// *DecodeError.
+func (m *OrderedMap) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	token, err := dec.Token()
+	if err != nil {
+		return decodeError(dec, err)
+	}
+
+	var keys, values []interface{}
+	switch token {
+	case nil:
+		return checkEOF(dec)
+
+	case json.Delim('{'):
+		keys, values, err = m.decodeObject(dec)
+
+	case json.Delim('['):
+		keys, values, err = m.decodePairs(dec)
+
+	default:
+		s, ok := token.(string)
+		if !ok {
+			return decodeError(dec, errors.New(
+				"invalid data, expected a JSON object or array"))
+		}
+
+		keys, values, err = decodeGob(s)
+	}
+
+	if err != nil {
+		return decodeError(dec, err)
+	}
+
+	if err = checkEOF(dec); err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	err = m.checkEntries(keys, values)
+	if err != nil {
+		return err
+	}
+
+	if m.unmarshalReset {
+		m.clear()
+	}
+
+	for i, key := range keys {
+		m.set(key, values[i])
+	}
+
+	return nil
+}
+
+// ParseJSON creates a new map configured with any options provided and adds the
+// keys and values from data to it, in the same way as UnmarshalJSON.
+func ParseJSON(data []byte, options ...Option) (*OrderedMap, error) {
+	m := NewOrderedMap(options...)
+	err := m.UnmarshalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// compactJSON is the format used by MarshalCompact and UnmarshalCompact.
+type compactJSON struct {
+	Values  []json.RawMessage    `json:"values"`
+	Entries [][2]json.RawMessage `json:"entries"`
+}
+
+// MarshalCompact encodes the map as JSON in a form that stores each distinct
+// value only once. The result is an object with a "values" array holding the
+// distinct values and an "entries" array of [key, index] pairs, in order, where
+// index refers to an element of "values". Values are considered the same when
+// json.Marshal encodes them to the same JSON.
+//
+// This can be much smaller than MarshalJSON when many keys share a value. Use
+// UnmarshalCompact to decode it.
+func (m *OrderedMap) MarshalCompact() ([]byte, error) {
+	keys, values := m.KeysValues()
+	compact := compactJSON{
+		Values:  []json.RawMessage{},
+		Entries: make([][2]json.RawMessage, len(keys)),
+	}
+	indexes := make(map[string]int)
+	for i, key := range keys {
+		rawKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		rawValue, err := json.Marshal(values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		index, ok := indexes[string(rawValue)]
+		if !ok {
+			index = len(compact.Values)
+			indexes[string(rawValue)] = index
+			compact.Values = append(compact.Values, rawValue)
+		}
+
+		rawIndex, _ := json.Marshal(index)
+		compact.Entries[i] = [2]json.RawMessage{rawKey, rawIndex}
+	}
+
+	return json.Marshal(compact)
+}
+
+// UnmarshalCompact adds the keys and values from data produced by
+// MarshalCompact, in order, to the map. Keys and values are decoded in the
+// same way as UnmarshalJSON. Each key gets its own copy of a shared value.
+//
+// Nothing is added to the map if data cannot be decoded.
+func (m *OrderedMap) UnmarshalCompact(data []byte) error {
+	var compact compactJSON
+	err := json.Unmarshal(data, &compact)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]interface{}, len(compact.Entries))
+	values := make([]interface{}, len(compact.Entries))
+	for i, entry := range compact.Entries {
+		keys[i], err = m.decodeKey(entry[0])
+		if err != nil {
+			return err
+		}
+
+		var index int
+		err = json.Unmarshal(entry[1], &index)
+		if err != nil {
+			return err
+		}
+
+		if index < 0 || index >= len(compact.Values) {
+			return fmt.Errorf(
+				"invalid data, value index %d out of range", index)
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(compact.Values[index]))
+		values[i], err = decodeValue(dec)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	err = m.checkEntries(keys, values)
+	if err != nil {
+		return err
+	}
+
+	if m.unmarshalReset {
+		m.clear()
+	}
+
+	for i, key := range keys {
+		m.set(key, values[i])
+	}
+
+	return nil
+}
+
+// decodeObject decodes the entries of a JSON object after its opening '{' has
+// been read, up to and including the closing '}'.
+func (m *OrderedMap) decodeObject(dec *json.Decoder) (
+	keys, values []interface{}, err error) {
+	seen := m.seenKeys()
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var key interface{} = token
+		if m.keyType != nil {
+			raw, _ := json.Marshal(token)
+			key, err = m.decodeKey(raw)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		err = seen.add(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value, err := m.decodeMapValue(dec)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	_, err = dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keys, values, nil
+}
+
+// decodePairs decodes an array of [key, value] pairs after its opening '[' has
+// been read, up to and including the closing ']'.
+func (m *OrderedMap) decodePairs(dec *json.Decoder) (
+	keys, values []interface{}, err error) {
+	seen := m.seenKeys()
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if token != json.Delim('[') || !dec.More() {
+			return nil, nil, ErrInvalidPairCount
+		}
+
+		var raw json.RawMessage
+		err = dec.Decode(&raw)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := m.decodeKey(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = seen.add(key)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !dec.More() {
+			return nil, nil, ErrInvalidPairCount
+		}
+
+		value, err := m.decodeMapValue(dec)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if dec.More() {
+			return nil, nil, ErrInvalidPairCount
+		}
+
+		_, err = dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	_, err = dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keys, values, nil
+}
+
+// keySet tracks the keys decoded so far for WithUniqueJSONKeys. A nil keySet
+// accepts every key.
+type keySet map[interface{}]struct{}
+
+func (m *OrderedMap) seenKeys() keySet {
+	if !m.uniqueJSONKeys {
+		return nil
+	}
+
+	return make(keySet)
+}
+
+// add returns ErrDuplicateKey if key has already been added.
+func (s keySet) add(key interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	if _, ok := s[key]; ok {
+		return ErrDuplicateKey
+	}
+
+	s[key] = struct{}{}
+
+	return nil
+}
+
+// decodeValue decodes the next JSON value like json.Unmarshal into an
+// interface{}, except that objects are decoded into an *OrderedMap so that the
+// order of their keys is kept.
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		m := NewOrderedMap()
+		keys, values, err := m.decodeObject(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, key := range keys {
+			m.set(key, values[i])
+		}
+
+		return m, nil
+
+	case json.Delim('['):
+		values := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		_, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		return values, nil
+	}
+
+	return token, nil
+}
+
+func (m *OrderedMap) decodeKey(raw json.RawMessage) (interface{}, error) {
+	if m.keyType != nil {
+		key := reflect.New(m.keyType)
+		err := json.Unmarshal(raw, key.Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		return key.Elem().Interface(), nil
+	}
+
+	var key interface{}
+	err := json.Unmarshal(raw, &key)
+	if err != nil {
+		return nil, err
+	}
+
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		return nil, fmt.Errorf("invalid data, key %s is not comparable", raw)
+	}
+
+	return key, nil
+}
+
+// RegisterGobType registers the concrete type of v with encoding/gob so that
+// values of that type can be decoded from data in the base64 encoded gob
+// format written by older versions of MarshalJSON. gob registers the basic
+// types itself: bools, ints, uints, floats, complex numbers, strings and slices
+// of those. Other types, such as structs, maps and pointers, must be
+// registered before decoding data that contains them, otherwise UnmarshalJSON
+// returns an error naming the type.
+//
+// Like gob.Register, it panics if a different type has already been
+// registered under the same name.
+func RegisterGobType(v interface{}) {
+	gob.Register(v)
+}
+
+// decodeGob decodes the base64 encoded gob format used by older versions of
+// MarshalJSON.
+func decodeGob(s string) (keys, values []interface{}, err error) {
+	bys, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var collection []interface{}
+	dec := gob.NewDecoder(bytes.NewReader(bys))
+	err = dec.Decode(&collection)
+	if err != nil {
+		if strings.Contains(err.Error(), "not registered") {
+			return nil, nil, fmt.Errorf(
+				"invalid data, %v (see RegisterGobType)", err)
+		}
+
+		return nil, nil, err
+	}
+
+	length := len(collection)
+	count := length >> 1
+	if count<<1 != length {
+		return nil, nil, ErrInvalidPairCount
+	}
+
+	var idx int
+	for i := 0; i < count; i++ {
+		idx = i << 1
+		keys = append(keys, collection[idx])
+		values = append(values, collection[idx+1])
+	}
+
+	return keys, values, nil
+}
@@ -0,0 +1,71 @@
+package orderedmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LockStats reports how often the lock of a map created with WithLockProfiling
+// has been taken and how long callers have waited for it.
+type LockStats struct {
+	// Acquisitions is the number of times the read or write lock was taken.
+	Acquisitions uint64
+
+	// Contended is the number of acquisitions that had to wait because the
+	// lock was already held.
+	Contended uint64
+
+	// Wait is the total time spent waiting in contended acquisitions.
+	Wait time.Duration
+}
+
+// lockCounters are updated atomically on every acquisition with
+// WithLockProfiling.
+type lockCounters struct {
+	acquisitions, contended, wait uint64
+}
+
+// record counts an acquisition that waited for wait, or zero if the lock was
+// free.
+func (c *lockCounters) record(wait time.Duration) {
+	atomic.AddUint64(&c.acquisitions, 1)
+	if wait > 0 {
+		atomic.AddUint64(&c.contended, 1)
+		atomic.AddUint64(&c.wait, uint64(wait))
+	}
+}
+
+// Lock takes the write lock, counting it with WithLockProfiling.
+func (m *OrderedMap) Lock() {
+	if m.lockStats == nil {
+		m.RWMutex.Lock()
+		return
+	}
+
+	m.lockStats.lock(&m.RWMutex)
+}
+
+// RLock takes the read lock, counting it with WithLockProfiling.
+func (m *OrderedMap) RLock() {
+	if m.lockStats == nil {
+		m.RWMutex.RLock()
+		return
+	}
+
+	m.lockStats.rlock(&m.RWMutex)
+}
+
+// LockStats returns the lock statistics collected since the map was created.
+// It returns zero statistics unless the map was created with
+// WithLockProfiling.
+func (m *OrderedMap) LockStats() LockStats {
+	if m.lockStats == nil {
+		return LockStats{}
+	}
+
+	return LockStats{
+		Acquisitions: atomic.LoadUint64(&m.lockStats.acquisitions),
+		Contended:    atomic.LoadUint64(&m.lockStats.contended),
+		Wait:         time.Duration(atomic.LoadUint64(&m.lockStats.wait)),
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !go1.18
+// +build !go1.18
+
+package orderedmap
+
+import (
+	"sync"
+	"time"
+)
+
+// contendedWait is how long an acquisition must take to count as contended,
+// since before Go 1.18 there is no way to try the lock first.
+const contendedWait = time.Microsecond
+
+// lock takes the write lock of mu, timing every acquisition.
+func (c *lockCounters) lock(mu *sync.RWMutex) {
+	start := time.Now()
+	mu.Lock()
+	c.record(waited(start))
+}
+
+// rlock takes the read lock of mu, timing every acquisition.
+func (c *lockCounters) rlock(mu *sync.RWMutex) {
+	start := time.Now()
+	mu.RLock()
+	c.record(waited(start))
+}
+
+// waited returns the time since start, or zero if it is too short to count as
+// contended.
+func waited(start time.Time) time.Duration {
+	if wait := time.Since(start); wait >= contendedWait {
+		return wait
+	}
+
+	return 0
+}
@@ -0,0 +1,46 @@
+package orderedmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_LockStats(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		assert.Equal(t, orderedmap.LockStats{}, m.LockStats())
+	})
+
+	t.Run("Uncontended", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithLockProfiling())
+		m.Set("a", 1)
+		m.Get("a")
+		stats := m.LockStats()
+		assert.Equal(t, uint64(2), stats.Acquisitions)
+		assert.Equal(t, uint64(0), stats.Contended)
+		assert.Equal(t, time.Duration(0), stats.Wait)
+	})
+
+	t.Run("Contended", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithLockProfiling())
+		m.Lock()
+		done := make(chan struct{})
+		go func() {
+			m.Set("a", 1)
+			close(done)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		m.Unlock()
+		<-done
+
+		stats := m.LockStats()
+		assert.Equal(t, uint64(2), stats.Acquisitions)
+		assert.Equal(t, uint64(1), stats.Contended)
+		assert.True(t, stats.Wait >= 5*time.Millisecond)
+	})
+}
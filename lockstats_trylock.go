@@ -0,0 +1,34 @@
+//go:build go1.18
+// +build go1.18
+
+package orderedmap
+
+import (
+	"sync"
+	"time"
+)
+
+// lock takes the write lock of mu, only timing it if it is already held.
+func (c *lockCounters) lock(mu *sync.RWMutex) {
+	if mu.TryLock() {
+		c.record(0)
+		return
+	}
+
+	start := time.Now()
+	mu.Lock()
+	c.record(time.Since(start))
+}
+
+// rlock takes the read lock of mu, only timing it if it cannot be taken
+// straight away.
+func (c *lockCounters) rlock(mu *sync.RWMutex) {
+	if mu.TryRLock() {
+		c.record(0)
+		return
+	}
+
+	start := time.Now()
+	mu.RLock()
+	c.record(time.Since(start))
+}
@@ -0,0 +1,108 @@
+package orderedmap
+
+import (
+	"container/list"
+	"sync"
+)
+
+type orderedMultiMapElement struct {
+	key    interface{}
+	values []interface{}
+}
+
+// OrderedMultiMap maps each key to multiple values. Keys are kept in the order
+// they were first added and the values of each key are kept in the order they
+// were added.
+type OrderedMultiMap struct {
+	kv map[interface{}]*list.Element
+	ll *list.List
+	sync.RWMutex
+}
+
+func NewOrderedMultiMap() *OrderedMultiMap {
+	return &OrderedMultiMap{
+		kv: make(map[interface{}]*list.Element),
+		ll: list.New(),
+	}
+}
+
+// Add appends a value to the values of key. If the key is new it is added to
+// the end of the keys.
+func (m *OrderedMultiMap) Add(key, value interface{}) {
+	m.Lock()
+	defer m.Unlock()
+	element, ok := m.kv[key]
+	if !ok {
+		element = m.ll.PushBack(&orderedMultiMapElement{key: key})
+		m.kv[key] = element
+	}
+
+	e := element.Value.(*orderedMultiMapElement)
+	e.values = append(e.values, value)
+}
+
+// GetAll returns a copy of the values for a key in the order they were added.
+// If the key does not exist nil is returned.
+func (m *OrderedMultiMap) GetAll(key interface{}) []interface{} {
+	m.RLock()
+	defer m.RUnlock()
+	element, ok := m.kv[key]
+	if !ok {
+		return nil
+	}
+
+	values := element.Value.(*orderedMultiMapElement).values
+
+	return append([]interface{}(nil), values...)
+}
+
+// Len returns the number of keys in the map.
+func (m *OrderedMultiMap) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.kv)
+}
+
+// Keys returns all of the keys in the order they were first added.
+func (m *OrderedMultiMap) Keys() (keys []interface{}) {
+	m.RLock()
+	defer m.RUnlock()
+	keys = make([]interface{}, 0, len(m.kv))
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*orderedMultiMapElement).key)
+	}
+
+	return keys
+}
+
+// Delete will remove a key and all of its values from the map. It will return
+// true if the key was removed (the key did exist).
+func (m *OrderedMultiMap) Delete(key interface{}) (didDelete bool) {
+	m.Lock()
+	defer m.Unlock()
+	element, ok := m.kv[key]
+	if ok {
+		m.ll.Remove(element)
+		delete(m.kv, key)
+	}
+
+	return ok
+}
+
+// ForEach calls fn for every value in the map. Keys are visited in the order
+// they were first added, and the values of each key in the order they were
+// added. Iteration stops if fn returns false.
+//
+// The map is read locked while iterating so fn must not modify the map.
+func (m *OrderedMultiMap) ForEach(fn func(key, value interface{}) bool) {
+	m.RLock()
+	defer m.RUnlock()
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		element := e.Value.(*orderedMultiMapElement)
+		for _, value := range element.values {
+			if !fn(element.key, value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrderedMultiMap(t *testing.T) {
+	m := orderedmap.NewOrderedMultiMap()
+	assert.IsType(t, &orderedmap.OrderedMultiMap{}, m)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestOrderedMultiMap_GetAll(t *testing.T) {
+	t.Run("MissingKeyIsNil", func(t *testing.T) {
+		m := orderedmap.NewOrderedMultiMap()
+		assert.Nil(t, m.GetAll("foo"))
+	})
+
+	t.Run("ValuesAreInOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMultiMap()
+		m.Add("foo", 1)
+		m.Add("bar", 2)
+		m.Add("foo", 3)
+		assert.Equal(t, []interface{}{1, 3}, m.GetAll("foo"))
+		assert.Equal(t, []interface{}{2}, m.GetAll("bar"))
+	})
+
+	t.Run("ReturnsCopy", func(t *testing.T) {
+		m := orderedmap.NewOrderedMultiMap()
+		m.Add("foo", 1)
+		m.GetAll("foo")[0] = 2
+		assert.Equal(t, []interface{}{1}, m.GetAll("foo"))
+	})
+}
+
+func TestOrderedMultiMap_Keys(t *testing.T) {
+	m := orderedmap.NewOrderedMultiMap()
+	m.Add("foo", 1)
+	m.Add("bar", 2)
+	m.Add("foo", 3)
+	assert.Equal(t, []interface{}{"foo", "bar"}, m.Keys())
+	assert.Equal(t, 2, m.Len())
+}
+
+func TestOrderedMultiMap_Delete(t *testing.T) {
+	m := orderedmap.NewOrderedMultiMap()
+	m.Add("foo", 1)
+	m.Add("bar", 2)
+	assert.True(t, m.Delete("foo"))
+	assert.False(t, m.Delete("foo"))
+	assert.Nil(t, m.GetAll("foo"))
+	assert.Equal(t, []interface{}{"bar"}, m.Keys())
+}
+
+func TestOrderedMultiMap_ForEach(t *testing.T) {
+	t.Run("VisitsValuesInOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMultiMap()
+		m.Add("foo", 1)
+		m.Add("bar", 2)
+		m.Add("foo", 3)
+
+		var results []interface{}
+		m.ForEach(func(key, value interface{}) bool {
+			results = append(results, key, value)
+			return true
+		})
+
+		assert.Equal(t, []interface{}{"foo", 1, "foo", 3, "bar", 2}, results)
+	})
+
+	t.Run("Stops", func(t *testing.T) {
+		m := orderedmap.NewOrderedMultiMap()
+		m.Add("foo", 1)
+		m.Add("foo", 2)
+
+		count := 0
+		m.ForEach(func(key, value interface{}) bool {
+			count++
+			return false
+		})
+
+		assert.Equal(t, 1, count)
+	})
+}
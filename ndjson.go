@@ -0,0 +1,112 @@
+package orderedmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ndjsonEntry is a single line written by WriteNDJSON.
+type ndjsonEntry struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// WriteNDJSON writes each entry in order to w as a JSON object on its own line,
+// in the form {"key":k,"value":v}. This newline delimited JSON can be read
+// back with ReadNDJSON or processed one line at a time by other tools. Keys and
+// values are encoded with json.Marshal.
+func (m *OrderedMap) WriteNDJSON(w io.Writer) error {
+	keys, values := m.KeysValues()
+
+	buf := bufio.NewWriter(w)
+	for i, key := range keys {
+		buf.WriteString(`{"key":`)
+		err := encodeValue(buf, key)
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString(`,"value":`)
+		err = encodeValue(buf, values[i])
+		if err != nil {
+			return err
+		}
+
+		buf.WriteString("}\n")
+	}
+
+	return buf.Flush()
+}
+
+// ReadNDJSON adds the entries read from r, in the format written by
+// WriteNDJSON, to the map in the order of the lines. Empty lines are ignored.
+// Keys and values are decoded in the same way as an array of pairs in
+// UnmarshalJSON, including WithJSONKeyType, and if a key appears more than once
+// the last value wins.
+//
+// Nothing is added to the map if any line cannot be decoded.
+func (m *OrderedMap) ReadNDJSON(r io.Reader) error {
+	var keys, values []interface{}
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		b, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if len(bytes.TrimSpace(b)) > 0 {
+			key, value, lineErr := m.decodeNDJSONLine(b)
+			if lineErr != nil {
+				return fmt.Errorf("invalid data, line %d: %v", line, lineErr)
+			}
+
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	err := m.checkEntries(keys, values)
+	if err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		m.set(key, values[i])
+	}
+
+	return nil
+}
+
+func (m *OrderedMap) decodeNDJSONLine(b []byte) (
+	key, value interface{}, err error) {
+	var entry ndjsonEntry
+	err = json.Unmarshal(b, &entry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if entry.Key == nil || entry.Value == nil {
+		return nil, nil, fmt.Errorf(`expected "key" and "value"`)
+	}
+
+	key, err = m.decodeKey(entry.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, err = decodeValue(json.NewDecoder(bytes.NewReader(entry.Value)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, value, nil
+}
@@ -0,0 +1,78 @@
+package orderedmap_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_WriteNDJSON(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, orderedmap.NewOrderedMap().WriteNDJSON(&buf))
+		assert.Equal(t, "", buf.String())
+	})
+
+	t.Run("Entries", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		m.Set(2, []string{"bar"})
+		var buf bytes.Buffer
+		assert.NoError(t, m.WriteNDJSON(&buf))
+		assert.Equal(t, `{"key":"foo","value":1}`+"\n"+
+			`{"key":2,"value":["bar"]}`+"\n", buf.String())
+	})
+
+	t.Run("MarshalError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", make(chan int))
+		var buf bytes.Buffer
+		assert.Error(t, m.WriteNDJSON(&buf))
+	})
+}
+
+func TestOrderedMap_ReadNDJSON(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		m.Set(2, "bar")
+		m.Set("baz", nil)
+		var buf bytes.Buffer
+		assert.NoError(t, m.WriteNDJSON(&buf))
+
+		m2 := orderedmap.NewOrderedMap(orderedmap.WithJSONKeyType(nil))
+		assert.NoError(t, m2.ReadNDJSON(&buf))
+		assert.Equal(t, []interface{}{"foo", 2.0, "baz"}, m2.Keys())
+		assert.True(t, m.EqualNumeric(m2))
+	})
+
+	t.Run("LineOrder", func(t *testing.T) {
+		data := `{"value":1,"key":"b"}` + "\n\n" +
+			`{"key":"a","value":{"y":1,"x":2}}` + "\n" +
+			`{"key":"b","value":3}`
+		m := orderedmap.NewOrderedMap()
+		assert.NoError(t, m.ReadNDJSON(strings.NewReader(data)))
+		assert.Equal(t, []interface{}{"b", "a"}, m.Keys())
+		assert.Equal(t, 3.0, m.GetOrDefault("b", nil))
+		nested := m.GetOrDefault("a", nil).(*orderedmap.OrderedMap)
+		assert.Equal(t, []interface{}{"y", "x"}, nested.Keys())
+	})
+
+	t.Run("InvalidLine", func(t *testing.T) {
+		data := `{"key":"a","value":1}` + "\n" + `{"key":"b"}` + "\n"
+		m := orderedmap.NewOrderedMap()
+		err := m.ReadNDJSON(strings.NewReader(data))
+		assert.EqualError(t, err,
+			`invalid data, line 2: expected "key" and "value"`)
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("InvalidJSON", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		err := m.ReadNDJSON(strings.NewReader(`{"key":`))
+		assert.Error(t, err)
+	})
+}
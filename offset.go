@@ -0,0 +1,11 @@
+//go:build go1.14
+// +build go1.14
+
+package orderedmap
+
+import "encoding/json"
+
+// inputOffset returns how far dec has read into its input.
+func inputOffset(dec *json.Decoder) int64 {
+	return dec.InputOffset()
+}
@@ -0,0 +1,12 @@
+//go:build !go1.14
+// +build !go1.14
+
+package orderedmap
+
+import "encoding/json"
+
+// inputOffset returns -1 since json.Decoder cannot report its offset before
+// Go 1.14.
+func inputOffset(dec *json.Decoder) int64 {
+	return -1
+}
@@ -0,0 +1,152 @@
+package orderedmap
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OpKind is the kind of change made by an Op.
+type OpKind int
+
+const (
+	// OpSet sets the Key of the Op to its Value, like Set.
+	OpSet OpKind = iota
+
+	// OpDelete removes the Key of the Op, like Delete.
+	OpDelete
+
+	// OpClear removes every key, like Clear. The Key and Value are ignored.
+	OpClear
+
+	// OpMoveToBack moves the Key of the Op to the back, if it exists. The
+	// Value is ignored.
+	OpMoveToBack
+)
+
+// Op is a single change to a map, such as an entry in an operation log. See
+// ApplyOps.
+type Op struct {
+	Kind       OpKind
+	Key, Value interface{}
+}
+
+// ApplyOps makes each of the changes in ops to the map, in order, under a
+// single lock. This can be used to rebuild a map from a log of operations.
+//
+// Every op is checked before any are applied, so the map is unchanged if an
+// error is returned: a descriptive error for an unknown Kind, the error from
+// checking the key or value of an OpSet (see WithStringKeysOnly and
+// WithValueValidator), or ErrSorted or ErrUnordered for an OpMoveToBack on a
+// map that cannot be reordered.
+func (m *OrderedMap) ApplyOps(ops []Op) error {
+	values := make([]interface{}, len(ops))
+	for i, op := range ops {
+		switch op.Kind {
+		case OpSet:
+			err := m.checkKey(op.Key)
+			if err != nil {
+				return err
+			}
+
+			values[i], err = m.validValue(op.Key, op.Value)
+			if err != nil {
+				return err
+			}
+
+		case OpDelete, OpClear:
+			// Any key can be deleted.
+
+		case OpMoveToBack:
+			if err := m.checkOrdered(); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("unknown op kind %d at index %d", op.Kind, i)
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	for i, op := range ops {
+		switch op.Kind {
+		case OpSet:
+			m.set(op.Key, values[i])
+
+		case OpDelete:
+			m.deleteKey(op.Key)
+
+		case OpClear:
+			m.clear()
+
+		case OpMoveToBack:
+			if element, ok := m.kv[op.Key]; ok && element != m.ll.Back() {
+				m.ll.MoveToBack(element)
+				m.version++
+			}
+		}
+	}
+
+	return nil
+}
+
+// Patch returns the ops that ApplyOps needs to turn m into a map with the same
+// keys, values and order as target, so that only the differences need to be
+// sent to keep a copy of a map in sync. The ops are, in this order:
+//
+//   - an OpDelete for each key that is not in target;
+//   - an OpSet for each key in the longest run at the front of target whose
+//     keys are already in m in the same relative order, if its value differs;
+//     and
+//   - for each of the remaining keys of target, in order, an OpSet if it is new
+//     or its value differs, and an OpMoveToBack if it was already in m.
+//
+// Values are compared with reflect.DeepEqual. The patch aims to be correct
+// rather than as small as possible: values that are equal but not deeply
+// equal, such as nested maps, are always set again. It takes O(n) time and
+// memory for the combined size of both maps.
+func (m *OrderedMap) Patch(target *OrderedMap) []Op {
+	keys, values := m.KeysValues()
+	targetKeys, targetValues := target.KeysValues()
+
+	inTarget := make(map[interface{}]struct{}, len(targetKeys))
+	for _, key := range targetKeys {
+		inTarget[key] = struct{}{}
+	}
+
+	var ops []Op
+	indexes := make(map[interface{}]int, len(keys))
+	for i, key := range keys {
+		if _, ok := inTarget[key]; ok {
+			indexes[key] = i
+		} else {
+			ops = append(ops, Op{Kind: OpDelete, Key: key})
+		}
+	}
+
+	// Keys that are already in order at the front of target stay where they
+	// are. Everything after them is added or moved to the back in turn.
+	stay := 0
+	last := -1
+	for ; stay < len(targetKeys); stay++ {
+		i, ok := indexes[targetKeys[stay]]
+		if !ok || i < last {
+			break
+		}
+
+		last = i
+	}
+
+	for i, key := range targetKeys {
+		index, existed := indexes[key]
+		if !existed || !reflect.DeepEqual(values[index], targetValues[i]) {
+			ops = append(ops, Op{Kind: OpSet, Key: key, Value: targetValues[i]})
+		}
+
+		if existed && i >= stay {
+			ops = append(ops, Op{Kind: OpMoveToBack, Key: key})
+		}
+	}
+
+	return ops
+}
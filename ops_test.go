@@ -0,0 +1,119 @@
+package orderedmap_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_ApplyOps(t *testing.T) {
+	t.Run("Replay", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("z", 0)
+		err := m.ApplyOps([]orderedmap.Op{
+			{Kind: orderedmap.OpClear},
+			{Kind: orderedmap.OpSet, Key: "a", Value: 1},
+			{Kind: orderedmap.OpSet, Key: "b", Value: 2},
+			{Kind: orderedmap.OpSet, Key: "c", Value: 3},
+			{Kind: orderedmap.OpDelete, Key: "b"},
+			{Kind: orderedmap.OpSet, Key: "a", Value: 4},
+			{Kind: orderedmap.OpDelete, Key: "missing"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []orderedmap.Entry{{"a", 4}, {"c", 3}},
+			m.SnapshotEntries())
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.NoError(t, m.ApplyOps(nil))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("UnknownKind", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		err := m.ApplyOps([]orderedmap.Op{
+			{Kind: orderedmap.OpSet, Key: "a", Value: 1},
+			{Kind: 42, Key: "b"},
+		})
+		assert.EqualError(t, err, "unknown op kind 42 at index 1")
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("NonStringKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithStringKeysOnly())
+		err := m.ApplyOps([]orderedmap.Op{
+			{Kind: orderedmap.OpSet, Key: "a", Value: 1},
+			{Kind: orderedmap.OpSet, Key: 2, Value: 2},
+		})
+		assert.Equal(t, orderedmap.ErrNonStringKey, err)
+		assert.Equal(t, 0, m.Len())
+	})
+	t.Run("MoveToBack", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		assert.NoError(t, m.ApplyOps([]orderedmap.Op{
+			{Kind: orderedmap.OpMoveToBack, Key: "a"},
+			{Kind: orderedmap.OpMoveToBack, Key: "missing"},
+		}))
+		assert.Equal(t, []interface{}{"b", "a"}, m.Keys())
+
+		sorted := orderedmap.NewSortedMap(func(a, b interface{}) bool {
+			return a.(string) < b.(string)
+		})
+		err := sorted.ApplyOps([]orderedmap.Op{
+			{Kind: orderedmap.OpMoveToBack, Key: "a"},
+		})
+		assert.Equal(t, orderedmap.ErrSorted, err)
+	})
+}
+
+func TestOrderedMap_Patch(t *testing.T) {
+	newMap := func(entries ...orderedmap.Entry) *orderedmap.OrderedMap {
+		m := orderedmap.NewOrderedMap()
+		for _, entry := range entries {
+			m.Set(entry.Key, entry.Value)
+		}
+
+		return m
+	}
+
+	t.Run("Equal", func(t *testing.T) {
+		m := newMap(orderedmap.Entry{"a", 1}, orderedmap.Entry{"b", 2})
+		assert.Nil(t, m.Patch(m.Slice(0, m.Len())))
+	})
+
+	t.Run("Ops", func(t *testing.T) {
+		m := newMap(orderedmap.Entry{"a", 1}, orderedmap.Entry{"b", 2},
+			orderedmap.Entry{"c", 3}, orderedmap.Entry{"d", 4})
+		target := newMap(orderedmap.Entry{"a", 1}, orderedmap.Entry{"c", 5},
+			orderedmap.Entry{"e", 6}, orderedmap.Entry{"b", 2})
+		assert.Equal(t, []orderedmap.Op{
+			{Kind: orderedmap.OpDelete, Key: "d"},
+			{Kind: orderedmap.OpSet, Key: "c", Value: 5},
+			{Kind: orderedmap.OpSet, Key: "e", Value: 6},
+			{Kind: orderedmap.OpMoveToBack, Key: "b"},
+		}, m.Patch(target))
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		randomMap := func() *orderedmap.OrderedMap {
+			m := orderedmap.NewOrderedMap()
+			for _, i := range r.Perm(10)[:r.Intn(10)] {
+				m.Set(i, r.Intn(3))
+			}
+
+			return m
+		}
+
+		for i := 0; i < 100; i++ {
+			m, target := randomMap(), randomMap()
+			assert.NoError(t, m.ApplyOps(m.Patch(target)))
+			assert.Equal(t, target.SnapshotEntries(), m.SnapshotEntries())
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package orderedmap
+
+import "reflect"
+
+// Option configures an OrderedMap when it is created with NewOrderedMap or
+// NewOrderedMapSize.
+type Option func(*OrderedMap)
+
+// WithPositionHistory remembers the index of up to size recently deleted keys
+// so that SetKeepingPosition can put them back where they were.
+//
+// Finding the index of a key requires walking the list, so Delete becomes
+// O(n) when this option is used. It is disabled by default.
+func WithPositionHistory(size int) Option {
+	return func(m *OrderedMap) {
+		m.maxPositions = size
+	}
+}
+
+// WithJSONKeyType makes UnmarshalJSON decode keys into the same type as sample,
+// rather than the types used by json.Unmarshal for an interface{}. For example,
+// WithJSONKeyType(0) decodes keys as ints instead of float64s.
+//
+// The type must be comparable to be used as a key. Struct keys must also be
+// marshalable by encoding/json.
+func WithJSONKeyType(sample interface{}) Option {
+	return func(m *OrderedMap) {
+		m.keyType = reflect.TypeOf(sample)
+	}
+}
+
+// WithCapacity limits the map to max keys. When a new key is added to a full
+// map the oldest key (the front) is evicted. A max of zero means no limit.
+func WithCapacity(max int) Option {
+	return func(m *OrderedMap) {
+		m.capacity = max
+	}
+}
+
+// WithEvictionHistory keeps the last size entries evicted by WithCapacity so
+// they can be inspected with EvictedHistory. Older entries are discarded so the
+// history never holds more than size entries.
+func WithEvictionHistory(size int) Option {
+	return func(m *OrderedMap) {
+		m.maxEvicted = size
+	}
+}
+
+// WithEvictionCallback calls fn with the key and value of every entry that is
+// evicted because of a capacity. It is called while the map is locked, so fn
+// must not use the map.
+func WithEvictionCallback(fn func(key, value interface{})) Option {
+	return func(m *OrderedMap) {
+		m.onEvict = fn
+	}
+}
+
+// WithAutoCompact rebuilds the internal hash map when a key is removed and the
+// number of keys has fallen below threshold times the most keys held since it
+// was last rebuilt. For example, 0.25 compacts once three quarters of the keys
+// have been removed. Each rebuild is O(n). It is disabled by default.
+func WithAutoCompact(threshold float64) Option {
+	return func(m *OrderedMap) {
+		m.autoCompact = threshold
+	}
+}
+
+// WithAppendOnly prevents the value of a key from being replaced once it has
+// been added. Set returns false and leaves the existing value unchanged, and
+// TrySet returns ErrKeyExists. The same applies to every other method that
+// would replace a value, such as SetWithTTL, SetBefore, MergeFront and
+// UnmarshalJSON (so the first of any duplicate keys is kept), although keys
+// can still be moved and deleted.
+func WithAppendOnly() Option {
+	return func(m *OrderedMap) {
+		m.appendOnly = true
+	}
+}
+
+// WithKeyInterning makes string keys share their storage with identical keys
+// in every other map created with this option. When many maps have the same
+// set of keys, such as maps decoded from a large number of similar JSON
+// objects, this saves keeping a separate copy of each key in every map.
+//
+// The shared keys are kept in a single table that is safe for concurrent use
+// by any number of maps. Each new key costs a lookup in the table, which
+// hashes the string, and strings in the table are never freed, so it should
+// only be used when the set of distinct keys is bounded.
+func WithKeyInterning() Option {
+	return func(m *OrderedMap) {
+		m.internKeys = true
+	}
+}
+
+// WithValueValidator runs validate on every value before it is stored, to
+// reject it or replace it with a cleaned up value. The value returned by
+// validate is stored instead of the original one. It must not call methods on
+// the map.
+//
+// It is used by Set, TrySet, SetWithTTL, SetBefore, SetAfter,
+// SetKeepingPosition, SetReturningElement, SetWithMeta, GetAndSet,
+// CompareAndSwap, MutateValue, TransformValues, GetOrCompute, MergeFront,
+// SetAllOrdered, FromSyncMap, ApplyOps, a BufferedWriter's Set, and for values
+// decoded by UnmarshalJSON, UnmarshalCompact and ReadNDJSON. When validate
+// returns an error the map is unchanged: TrySet, SetBefore, SetAfter,
+// ApplyOps and the decoders return the error, TransformValues stops as though
+// fn had returned it, and the other methods panic.
+//
+// Validation happens before the value is stored, so a rejected value never
+// causes anything to be evicted by a capacity limit.
+func WithValueValidator(
+	validate func(key, value interface{}) (interface{}, error)) Option {
+	return func(m *OrderedMap) {
+		m.validator = validate
+	}
+}
+
+// WithStringKeysOnly only allows keys that are strings, which guarantees that
+// the map is always encoded as a JSON object. Set and other methods that add a
+// key panic when given a key that is not a string, TrySet returns
+// ErrNonStringKey and UnmarshalJSON returns ErrNonStringKey without adding
+// anything.
+func WithStringKeysOnly() Option {
+	return func(m *OrderedMap) {
+		m.stringKeysOnly = true
+	}
+}
+
+// WithLFU makes capacity eviction remove the least frequently used entry
+// rather than the oldest. Each entry counts how many times it is read with Get
+// or GetOrDefault (see Frequency), and the entry with the lowest count is
+// evicted, with ties going to the oldest. The entry being set is never chosen
+// while there are others to evict.
+//
+// Finding the entry to evict walks the whole map, so each eviction is O(n).
+func WithLFU() Option {
+	return func(m *OrderedMap) {
+		m.lfu = true
+	}
+}
+
+// WithEvictionPolicy makes capacity eviction remove the key chosen by policy,
+// rather than the oldest key, so that custom policies such as random or
+// segmented LRU can be used. NewFIFOPolicy and NewLRUPolicy are provided. It
+// takes precedence over WithLFU.
+//
+// Get and GetOrDefault take the write lock, since they report reads to the
+// policy. Each map needs its own policy.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(m *OrderedMap) {
+		m.policy = policy
+	}
+}
+
+// WithValueIndex keeps a secondary index from values to keys so that
+// KeysForValue does not need to compare every value in the map. Values are
+// considered equal when eq returns true, and eq must be consistent with itself
+// since each value is only compared with one value from each group of equal
+// values.
+//
+// The index is only built when this option is used. It costs a map entry for
+// every key plus a small record for each distinct value, and every Set and
+// Delete compares against the distinct values, so it is best suited to small
+// maps or maps with few distinct values.
+func WithValueIndex(eq func(a, b interface{}) bool) Option {
+	return func(m *OrderedMap) {
+		m.values = &valueIndex{eq: eq}
+	}
+}
+
+// WithGetPromotesRecency chooses what the order of the map, and so capacity
+// eviction, is based on:
+//
+//   - false (the default) keeps keys in the order they were inserted, so
+//     WithCapacity evicts the key that was added first, however often it is
+//     read.
+//   - true moves a key to the back each time it is read with Get or
+//     GetOrDefault, so WithCapacity evicts the least recently used key. Reads
+//     take the write lock since they change the order.
+//
+// Use Peek to read a key without moving it. Setting a key never moves it in
+// either mode.
+func WithGetPromotesRecency(promote bool) Option {
+	return func(m *OrderedMap) {
+		m.getPromotes = promote
+	}
+}
+
+// WithCopyOnWrite makes Keys, Len and ForEach read from an immutable snapshot
+// of the map instead of taking the read lock, so they never wait for writers
+// and writers never wait for them. ForEach may also modify the map from fn.
+// Other methods lock as usual.
+//
+// The snapshot is copied in full each time the write lock is released after a
+// change, so every write costs O(n) time and a new allocation of the size of
+// the map, and an old snapshot stays in memory while it is still being read.
+// It suits maps that are iterated much more often than they are changed.
+func WithCopyOnWrite() Option {
+	return func(m *OrderedMap) {
+		m.copyOnWrite = true
+		m.snapshot.Store([]Entry{})
+	}
+}
+
+// WithUniqueJSONKeys makes UnmarshalJSON reject data that has the same key more
+// than once, returning a *DecodeError wrapping ErrDuplicateKey, rather than
+// keeping the last value. This only applies to the top level of the data.
+func WithUniqueJSONKeys() Option {
+	return func(m *OrderedMap) {
+		m.uniqueJSONKeys = true
+	}
+}
+
+// WithTypedJSON makes MarshalJSON wrap each value in an envelope that records
+// its Go type, such as {"t":"int","v":5}, and UnmarshalJSON restore the value
+// to that type. Without it every number comes back as a float64, so this makes
+// round trips lossless at the cost of larger JSON. Both sides need the option.
+//
+// The types recorded are int, int64, float64, string, bool and *OrderedMap.
+// Nested maps have their values wrapped too, and are decoded into maps that
+// also use WithTypedJSON. Values of any other type, including nil, are written
+// as {"v":value} and decoded in the same way as without the option. Keys are
+// not wrapped; use WithJSONKeyType to decode keys that are not strings.
+func WithTypedJSON() Option {
+	return func(m *OrderedMap) {
+		m.typedJSON = true
+	}
+}
+
+// WithUnmarshalReset makes UnmarshalJSON and UnmarshalCompact replace the
+// contents of the map with the decoded entries, as if Clear was called first.
+// Without it the decoded entries are merged into the map: existing keys not in
+// the data are kept, and keys in the data replace their values but keep their
+// positions.
+//
+// The map is only cleared once the data has been decoded, so it is unchanged if
+// decoding fails. A JSON null leaves the map unchanged either way.
+func WithUnmarshalReset() Option {
+	return func(m *OrderedMap) {
+		m.unmarshalReset = true
+	}
+}
+
+// WithLockProfiling counts how often the map's lock is taken, how often it was
+// already held, and how long callers waited for it, which can be read with
+// LockStats. This helps to decide whether a map is contended enough to be worth
+// splitting up.
+//
+// Every acquisition costs an atomic add, and one that has to wait is timed.
+// Before Go 1.18 every acquisition is timed, and only waits of at least a
+// microsecond count as contended.
+func WithLockProfiling() Option {
+	return func(m *OrderedMap) {
+		m.lockStats = new(lockCounters)
+	}
+}
+
+// WithoutOrdering stops the map from keeping track of the order of its keys,
+// so that Set and Delete only update the underlying Go map. Keys, KeysValues,
+// SnapshotEntries, ForEach and JSON encoding all still work, but return the
+// keys in the random order of a Go map, which may be different every time.
+//
+// Methods that depend on the order are not supported. SetBefore, SetAfter,
+// MoveToIndex and ReorderByKeys return ErrUnordered. Front, Back, Middle, All,
+// Backward, PopFront and capacity eviction behave as though the map is empty,
+// and methods that start from a key and walk the order, such as Next, Prev,
+// SplitAt and TruncateFrom, only see that key. GetWithIndex and
+// SearchInsertIndex return an index of -1, WithPositionHistory has nothing to
+// remember, and Fingerprint ignores the order of the entries.
+func WithoutOrdering() Option {
+	return func(m *OrderedMap) {
+		m.unordered = true
+	}
+}
@@ -1,57 +1,377 @@
 package orderedmap
 
 import (
-	"bytes"
 	"container/list"
-	"encoding/gob"
-	"encoding/json"
-	"sync"
 	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrKeyNotFound is returned when an operation requires a key that does not
+// exist in the map.
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrKeyExists is returned when an operation would replace the value of a key
+// that already exists and that is not allowed.
+var ErrKeyExists = errors.New("key already exists")
+
+// ErrNonStringKey is returned when a map created with WithStringKeysOnly is
+// given a key that is not a string.
+var ErrNonStringKey = errors.New("key is not a string")
+
+// ErrSorted is returned when a map created with NewSortedMap is asked to put a
+// key at a particular position.
+var ErrSorted = errors.New("map is sorted")
+
+// ErrUnordered is returned when a map created with WithoutOrdering is asked to
+// put a key at a particular position.
+var ErrUnordered = errors.New("map is not ordered")
+
 type orderedMapElement struct {
 	key, value interface{}
+
+	// expires is the time in unix nanoseconds that the element expires, or
+	// zero if it never expires.
+	expires int64
+
+	// hits is the number of times the element has been read, see WithLFU.
+	hits uint32
+
+	// version is the Version of the map when the element was added or its
+	// value last changed, see EntriesSince.
+	version uint64
+
+	// meta is the metadata attached with SetWithMeta, or nil if there is
+	// none. It is a pointer so that it costs little when unused.
+	meta *entryMeta
+}
+
+// entryMeta holds the metadata of an element, see SetWithMeta.
+type entryMeta struct {
+	value interface{}
+}
+
+// expired reports whether the element had a TTL which has now passed.
+func (e *orderedMapElement) expired() bool {
+	return e.expires != 0 && time.Now().UnixNano() >= e.expires
+}
+
+type keyPosition struct {
+	key   interface{}
+	index int
 }
 
+// OrderedMap is a map that remembers the order keys were added in. It is safe
+// for concurrent use. The zero value is an empty map ready to use, although
+// options can only be given to NewOrderedMap.
 type OrderedMap struct {
 	kv map[interface{}]*list.Element
-	ll *list.List
+	ll list.List
 	sync.RWMutex
+
+	// positions remembers where recently deleted keys were so that
+	// SetKeepingPosition can restore them. It holds at most maxPositions
+	// entries, oldest first.
+	positions    []keyPosition
+	maxPositions int
+
+	// keyType is the type that keys are decoded into by UnmarshalJSON, or nil
+	// to decode them like any other JSON value.
+	keyType reflect.Type
+
+	// cond is created on demand to wake goroutines blocked in PopFrontWait.
+	cond *sync.Cond
+
+	// capacity is the maximum number of keys before the oldest are evicted,
+	// or zero for no limit.
+	capacity int
+
+	// evicted is a ring buffer of the most recently evicted entries. The
+	// oldest entry is at evictedNext once the buffer is full.
+	evicted     []Entry
+	evictedNext int
+	maxEvicted  int
+
+	// onEvict is called for each entry evicted because of a capacity.
+	onEvict func(key, value interface{})
+
+	// When sizer is not nil, size is the total size of all entries as
+	// measured by EstimatedSize, and entries are evicted while it is greater
+	// than maxSize.
+	sizer   func(key, value interface{}) int
+	size    int
+	maxSize int
+
+	// peak is the most keys held since kv was last rebuilt. When autoCompact
+	// is not zero kv is rebuilt once the ratio of keys to peak falls below it.
+	peak        int
+	autoCompact float64
+
+	// appendOnly prevents the values of existing keys from being replaced.
+	appendOnly bool
+
+	// version is incremented on every change, see Version.
+	version uint64
+
+	// stringKeysOnly rejects new keys that are not strings.
+	stringKeysOnly bool
+
+	// validator checks or transforms values before they are set, see
+	// WithValueValidator.
+	validator func(key, value interface{}) (interface{}, error)
+
+	// store is written through to, see NewOrderedMapFromStore.
+	store Store
+
+	// lfu evicts the least frequently used entry rather than the oldest.
+	lfu bool
+
+	// policy chooses which entry to evict, see WithEvictionPolicy.
+	policy EvictionPolicy
+
+	// setPromotes moves a key to the back when Set replaces its value, see
+	// NewRecencyCache.
+	setPromotes bool
+
+	// lockStats counts lock acquisitions, see WithLockProfiling.
+	lockStats *lockCounters
+
+	// values indexes keys by value when WithValueIndex is used.
+	values *valueIndex
+
+	// uniqueJSONKeys makes UnmarshalJSON reject duplicate keys.
+	uniqueJSONKeys bool
+
+	// unmarshalReset makes UnmarshalJSON replace the contents of the map.
+	unmarshalReset bool
+
+	// internKeys shares the storage of string keys, see WithKeyInterning.
+	internKeys bool
+
+	// typedJSON wraps JSON values in typed envelopes, see WithTypedJSON.
+	typedJSON bool
+
+	// unordered skips linking elements into ll, see WithoutOrdering.
+	unordered bool
+
+	// less keeps the keys sorted for NewSortedMap, or is nil to keep them in
+	// insertion order.
+	less func(a, b interface{}) bool
+
+	// getPromotes moves keys to the back when they are read, see
+	// WithGetPromotesRecency.
+	getPromotes bool
+
+	// With copyOnWrite, snapshot holds a []Entry copy of the map as it was at
+	// snapshotVersion. It is replaced whenever the write lock is released
+	// after a change, so that it can be read without locking.
+	copyOnWrite     bool
+	snapshot        atomic.Value
+	snapshotVersion uint64
+}
+
+// NewOrderedMap creates an empty map configured with any options provided.
+func NewOrderedMap(options ...Option) *OrderedMap {
+	return NewOrderedMapSize(0, options...)
 }
 
-func NewOrderedMap() *OrderedMap {
-	return &OrderedMap{
-		kv: make(map[interface{}]*list.Element),
-		ll: list.New(),
+// NewOrderedMapSize creates a map with space preallocated for capacity keys.
+// This avoids growing the map repeatedly when the number of keys is known
+// ahead of time.
+func NewOrderedMapSize(capacity int, options ...Option) *OrderedMap {
+	m := &OrderedMap{
+		kv: make(map[interface{}]*list.Element, capacity),
+	}
+
+	for _, option := range options {
+		option(m)
 	}
+
+	return m
 }
 
 // Get returns the value for a key. If the key does not exist, the second return
 // parameter will be false and the value will be nil.
 func (m *OrderedMap) Get(key interface{}) (interface{}, bool) {
-	m.RLock()
-	defer m.RUnlock()
+	if m.getPromotes || m.policy != nil {
+		m.Lock()
+		defer m.Unlock()
+		if m.getPromotes {
+			m.promote(key)
+		}
+	} else {
+		m.RLock()
+		defer m.RUnlock()
+	}
+
+	m.hit(key)
+	return m.get(key)
+}
+
+// get is Get without locking. The caller must hold the lock.
+func (m *OrderedMap) get(key interface{}) (interface{}, bool) {
 	value, ok := m.kv[key]
-	if ok {
+	if ok && !value.Value.(*orderedMapElement).expired() {
 		return value.Value.(*orderedMapElement).value, true
 	}
 
 	return nil, false
 }
 
+// Has returns true if the key exists in the map.
+func (m *OrderedMap) Has(key interface{}) bool {
+	m.RLock()
+	defer m.RUnlock()
+	_, ok := m.get(key)
+	return ok
+}
+
+// ContainsAll returns true if every one of keys exists in the map, checked
+// under a single lock. It stops at the first missing key. It returns true if no
+// keys are given.
+func (m *OrderedMap) ContainsAll(keys ...interface{}) bool {
+	m.RLock()
+	defer m.RUnlock()
+	for _, key := range keys {
+		if _, ok := m.get(key); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ContainsAny returns true if at least one of keys exists in the map, checked
+// under a single lock. It stops at the first key found. It returns false if no
+// keys are given.
+func (m *OrderedMap) ContainsAny(keys ...interface{}) bool {
+	m.RLock()
+	defer m.RUnlock()
+	for _, key := range keys {
+		if _, ok := m.get(key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContainsValue returns true if any key that has not expired has a value equal
+// to value according to eq, or reflect.DeepEqual if eq is nil. Values are
+// checked in order and it stops at the first match, so it is O(n).
+func (m *OrderedMap) ContainsValue(value interface{},
+	eq func(a, b interface{}) bool) bool {
+	if eq == nil {
+		eq = reflect.DeepEqual
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+	found := false
+	m.elements(func(e *list.Element) bool {
+		element := e.Value.(*orderedMapElement)
+		found = !element.expired() && eq(element.value, value)
+		return !found
+	})
+
+	return found
+}
+
+// GetWithIndex returns the value for a key and its position in insertion order.
+// Finding the index walks the list so it is O(n). If the key does not exist the
+// value will be nil, the index will be -1 and ok will be false. The index is
+// also -1 for a map created with WithoutOrdering, which has no positions.
+func (m *OrderedMap) GetWithIndex(key interface{}) (
+	value interface{}, index int, ok bool) {
+	m.RLock()
+	defer m.RUnlock()
+	element, ok := m.kv[key]
+	if !ok || element.Value.(*orderedMapElement).expired() {
+		return nil, -1, false
+	}
+
+	return element.Value.(*orderedMapElement).value, m.indexOf(element), true
+}
+
 // Set will set (or replace) a value for a key. If the key was new, then true
 // will be returned. The returned value will be false if the value was replaced
 // (even if the value was the same).
 func (m *OrderedMap) Set(key, value interface{}) bool {
+	value = m.mustValidValue(key, value)
+	m.Lock()
+	defer m.Unlock()
+	return m.set(key, value)
+}
+
+// TrySet is like Set but returns an error instead of ignoring or panicking on a
+// key or value that the map does not accept:
+//
+//   - ErrKeyExists if the map was created with WithAppendOnly and the key
+//     already exists.
+//   - ErrNonStringKey if the map was created with WithStringKeysOnly and the
+//     key is not a string.
+//   - The error from the validator if the map was created with
+//     WithValueValidator and the value was rejected.
+func (m *OrderedMap) TrySet(key, value interface{}) error {
+	value, err := m.validValue(key, value)
+	if err != nil {
+		return err
+	}
+
 	m.Lock()
 	defer m.Unlock()
+	if _, ok := m.kv[key]; ok && m.appendOnly {
+		return ErrKeyExists
+	}
+
+	if err := m.checkKey(key); err != nil {
+		return err
+	}
+
+	m.set(key, value)
+
+	return nil
+}
+
+// SetReturningElement sets a value for a key like Set and returns the element
+// for the key, which can be used to move to its neighbours with Next and Prev.
+// isNew is true if the key did not already exist.
+//
+// element will be nil if the key was evicted straight away because it would
+// not fit in the map on its own (see NewOrderedMapWithByteCapacity).
+func (m *OrderedMap) SetReturningElement(key, value interface{}) (
+	element *Element, isNew bool) {
+	value = m.mustValidValue(key, value)
+	m.Lock()
+	defer m.Unlock()
+	isNew = m.set(key, value)
+
+	return newElement(m.kv[key]), isNew
+}
+
+// set is Set without locking. The caller must hold the write lock.
+func (m *OrderedMap) set(key, value interface{}) bool {
 	_, didExist := m.kv[key]
 
 	if !didExist {
-		element := m.ll.PushBack(&orderedMapElement{key, value})
-		m.kv[key] = element
+		m.mustCheckKey(key)
+		m.lazyInit()
+		key = m.intern(key)
+		element := &orderedMapElement{key: key, value: value}
+		m.kv[key] = m.push(element)
+		m.inserted(element)
 	} else {
-		m.kv[key].Value.(*orderedMapElement).value = value
+		m.replace(m.kv[key].Value.(*orderedMapElement), value)
+		if m.setPromotes {
+			m.promote(key)
+		}
 	}
 
 	return !didExist
@@ -60,10 +380,22 @@ func (m *OrderedMap) Set(key, value interface{}) bool {
 // GetOrDefault returns the value for a key. If the key does not exist, returns
 // the default value instead.
 func (m *OrderedMap) GetOrDefault(key, defaultValue interface{}) interface{} {
-	m.RLock()
-	defer m.RUnlock()
+	if m.getPromotes || m.policy != nil {
+		m.Lock()
+		defer m.Unlock()
+		if m.getPromotes {
+			m.promote(key)
+		}
+	} else {
+		m.RLock()
+		defer m.RUnlock()
+	}
+
+	m.hit(key)
 	if value, ok := m.kv[key]; ok {
-		return value.Value.(*orderedMapElement).value
+		if element := value.Value.(*orderedMapElement); !element.expired() {
+			return element.value
+		}
 	}
 
 	return defaultValue
@@ -71,6 +403,10 @@ func (m *OrderedMap) GetOrDefault(key, defaultValue interface{}) interface{} {
 
 // Len returns the number of elements in the map.
 func (m *OrderedMap) Len() int {
+	if m.copyOnWrite {
+		return len(m.entries())
+	}
+
 	m.RLock()
 	defer m.RUnlock()
 	return len(m.kv)
@@ -79,32 +415,331 @@ func (m *OrderedMap) Len() int {
 // Keys returns all of the keys in the order they were inserted. If a key was
 // replaced it will retain the same position. To ensure most recently set keys
 // are always at the end you must always Delete before Set.
+//
+// Other goroutines may change the map between a call to Keys and a later call,
+// so use KeysValues when the keys and values must match each other.
 func (m *OrderedMap) Keys() (keys []interface{}) {
+	if m.copyOnWrite {
+		entries := m.entries()
+		keys = make([]interface{}, len(entries))
+		for i, entry := range entries {
+			keys[i] = entry.Key
+		}
+
+		return keys
+	}
+
 	m.RLock()
 	defer m.RUnlock()
-	keys = make([]interface{}, m.Len())
+	return m.keys()
+}
 
-	element := m.ll.Front()
-	for i := 0; element != nil; i++ {
-		keys[i] = element.Value.(*orderedMapElement).key
-		element = element.Next()
+// AppendKeys appends the keys to dst, in order, and returns the extended slice.
+// It is a lower allocation alternative to Keys for callers that read the keys
+// often, as the same buffer can be reused by passing it back in as dst[:0].
+//
+// The returned slice belongs to the caller and is not changed by later changes
+// to the map, but it must not be reused while anything is still reading the
+// keys from an earlier call.
+func (m *OrderedMap) AppendKeys(dst []interface{}) []interface{} {
+	if m.copyOnWrite {
+		for _, entry := range m.entries() {
+			dst = append(dst, entry.Key)
+		}
+
+		return dst
 	}
 
+	m.RLock()
+	defer m.RUnlock()
+	m.elements(func(e *list.Element) bool {
+		dst = append(dst, e.Value.(*orderedMapElement).key)
+		return true
+	})
+
+	return dst
+}
+
+// keys is Keys without locking. The caller must hold the lock.
+func (m *OrderedMap) keys() (keys []interface{}) {
+	keys = make([]interface{}, 0, len(m.kv))
+	m.elements(func(e *list.Element) bool {
+		keys = append(keys, e.Value.(*orderedMapElement).key)
+		return true
+	})
+
+	return keys
+}
+
+// SortedKeys returns a copy of the keys sorted by less. Keys that are equal
+// according to less stay in insertion order. The order of the map itself is not
+// changed.
+func (m *OrderedMap) SortedKeys(
+	less func(a, b interface{}) bool) []interface{} {
+	keys := m.Keys()
+	sort.SliceStable(keys, func(i, j int) bool {
+		return less(keys[i], keys[j])
+	})
+
 	return keys
 }
 
+// KeyTypes returns the distinct types of the keys in the map, in the order
+// they are first seen. An empty map returns nil.
+//
+// MarshalJSON only writes a JSON object when every key is a string (or an
+// encoding.TextMarshaler), so more than one type, or a single non-string type,
+// means the map will be written as an array of pairs instead.
+func (m *OrderedMap) KeyTypes() (types []reflect.Type) {
+	m.RLock()
+	defer m.RUnlock()
+
+	seen := make(map[reflect.Type]bool)
+	m.elements(func(e *list.Element) bool {
+		t := reflect.TypeOf(e.Value.(*orderedMapElement).key)
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+
+		return true
+	})
+
+	return types
+}
+
+// KeysValues returns all of the keys and their values in the order they were
+// inserted. The two slices are index-aligned and are built in a single pass
+// under one lock, so they are always consistent with each other.
+func (m *OrderedMap) KeysValues() (keys, values []interface{}) {
+	m.RLock()
+	defer m.RUnlock()
+	keys = make([]interface{}, 0, len(m.kv))
+	values = make([]interface{}, 0, len(m.kv))
+	m.elements(func(e *list.Element) bool {
+		element := e.Value.(*orderedMapElement)
+		keys = append(keys, element.key)
+		values = append(values, element.value)
+		return true
+	})
+
+	return keys, values
+}
+
+// SnapshotEntries returns a copy of all of the entries in the order they were
+// inserted. The copy is taken under a single lock so it is consistent, and it
+// can be used freely afterwards without holding any lock on the map.
+func (m *OrderedMap) SnapshotEntries() []Entry {
+	m.RLock()
+	defer m.RUnlock()
+	entries := make([]Entry, 0, len(m.kv))
+	m.elements(func(element *list.Element) bool {
+		e := element.Value.(*orderedMapElement)
+		entries = append(entries, Entry{e.key, e.value})
+		return true
+	})
+
+	return entries
+}
+
+// Sample returns up to n entries chosen at random, in the order they appear in
+// the map. Every entry is equally likely to be chosen. r is the source of
+// randomness, or nil to use the default source of math/rand; passing a
+// seeded *rand.Rand makes the sample repeatable.
+//
+// The entries are chosen in a single pass with reservoir sampling, so it takes
+// O(len) time but only O(n) extra memory.
+func (m *OrderedMap) Sample(n int, r *rand.Rand) []Entry {
+	if n <= 0 {
+		return nil
+	}
+
+	intn := rand.Intn
+	if r != nil {
+		intn = r.Intn
+	}
+
+	type sample struct {
+		index int
+		entry Entry
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+	var samples []sample
+	i := 0
+	m.elements(func(element *list.Element) bool {
+		e := element.Value.(*orderedMapElement)
+		if i < n {
+			samples = append(samples, sample{i, Entry{e.key, e.value}})
+		} else if j := intn(i + 1); j < n {
+			samples[j] = sample{i, Entry{e.key, e.value}}
+		}
+
+		i++
+		return true
+	})
+
+	sort.Slice(samples, func(a, b int) bool {
+		return samples[a].index < samples[b].index
+	})
+
+	entries := make([]Entry, len(samples))
+	for i, s := range samples {
+		entries[i] = s.entry
+	}
+
+	return entries
+}
+
 // Delete will remove a key from the map. It will return true if the key was
 // removed (the key did exist).
 func (m *OrderedMap) Delete(key interface{}) (didDelete bool) {
 	m.Lock()
 	defer m.Unlock()
+	_, didDelete = m.deleteKey(key)
+	return didDelete
+}
+
+// DeleteReturning is like Delete but also returns the value that was removed.
+// If the key did not exist the value will be nil and existed will be false.
+func (m *OrderedMap) DeleteReturning(key interface{}) (
+	value interface{}, existed bool) {
+	m.Lock()
+	defer m.Unlock()
+	return m.deleteKey(key)
+}
+
+// deleteKey is Delete without locking. The caller must hold the write lock.
+func (m *OrderedMap) deleteKey(key interface{}) (interface{}, bool) {
 	element, ok := m.kv[key]
-	if ok {
-		m.ll.Remove(element)
-		delete(m.kv, key)
+	if !ok {
+		return nil, false
 	}
 
-	return ok
+	if m.maxPositions > 0 && !m.unordered {
+		m.rememberPosition(key, m.indexOf(element))
+	}
+
+	return m.remove(element).value, true
+}
+
+// Retain removes every key that is not one of keys and returns the number of
+// keys removed. The remaining keys keep their order. Keys that are not in the
+// map are ignored.
+func (m *OrderedMap) Retain(keys ...interface{}) (count int) {
+	keep := make(map[interface{}]struct{}, len(keys))
+	for _, key := range keys {
+		keep[key] = struct{}{}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	m.elements(func(element *list.Element) bool {
+		key := element.Value.(*orderedMapElement).key
+		if _, ok := keep[key]; !ok {
+			m.deleteKey(key)
+			count++
+		}
+
+		return true
+	})
+
+	return count
+}
+
+// TruncateFrom removes key and every key after it, returning the number of keys
+// removed. It returns 0 if the key does not exist. This can be used to roll
+// back to an earlier point when the map is used as an append-only log.
+func (m *OrderedMap) TruncateFrom(key interface{}) (count int) {
+	m.Lock()
+	defer m.Unlock()
+	element, ok := m.kv[key]
+	if !ok {
+		return 0
+	}
+
+	for element != nil {
+		next := element.Next()
+		m.deleteKey(element.Value.(*orderedMapElement).key)
+		count++
+		element = next
+	}
+
+	return count
+}
+
+// SetKeepingPosition sets a value for a key like Set. However, if the key does
+// not exist but was recently deleted it is inserted back at the index it was
+// deleted from, rather than at the back. The index is clamped to the current
+// length of the map.
+//
+// Only the positions of the most recently deleted keys are remembered, see
+// WithPositionHistory. Keys that are not remembered are added to the back.
+func (m *OrderedMap) SetKeepingPosition(key, value interface{}) {
+	value = m.mustValidValue(key, value)
+	m.Lock()
+	defer m.Unlock()
+
+	if element, ok := m.kv[key]; ok {
+		m.replace(element.Value.(*orderedMapElement), value)
+		return
+	}
+
+	m.mustCheckKey(key)
+	m.lazyInit()
+	key = m.intern(key)
+	newElement := &orderedMapElement{key: key, value: value}
+	index, ok := m.forgetPosition(key)
+	defer m.inserted(newElement)
+	if !ok || index >= m.ll.Len() || m.less != nil || m.unordered {
+		m.kv[key] = m.push(newElement)
+		return
+	}
+
+	mark := m.ll.Front()
+	for i := 0; i < index; i++ {
+		mark = mark.Next()
+	}
+
+	m.kv[key] = m.ll.InsertBefore(newElement, mark)
+}
+
+// indexOf returns the position of element by walking the list from the front.
+func (m *OrderedMap) indexOf(element *list.Element) int {
+	if m.unordered {
+		return -1
+	}
+
+	index := 0
+	for e := m.ll.Front(); e != element; e = e.Next() {
+		index++
+	}
+
+	return index
+}
+
+// rememberPosition records the index a key was deleted from, discarding the
+// oldest record if the history is full.
+func (m *OrderedMap) rememberPosition(key interface{}, index int) {
+	m.forgetPosition(key)
+	if len(m.positions) == m.maxPositions {
+		m.positions = append(m.positions[:0], m.positions[1:]...)
+	}
+
+	m.positions = append(m.positions, keyPosition{key, index})
+}
+
+// forgetPosition removes and returns the remembered index for a key.
+func (m *OrderedMap) forgetPosition(key interface{}) (int, bool) {
+	for i, position := range m.positions {
+		if position.key == key {
+			m.positions = append(m.positions[:i], m.positions[i+1:]...)
+			return position.index, true
+		}
+	}
+
+	return 0, false
 }
 
 // Front will return the element that is the first (oldest Set element). If
@@ -145,53 +780,1148 @@ func (m *OrderedMap) Back() *Element {
 	}
 }
 
-// marshal json to save
-func (m *OrderedMap) MarshalJSON() ([]byte, error) {
-	var keys = m.Keys()
-	var collection = make([]interface{}, 0, len(keys)*2)
-	var data interface{}
-	for _, key := range keys {
-		data, _ = m.Get(key)
-		collection = append(collection, key)
-		collection = append(collection, data)
-	}
+// Ends returns the first and last elements, like Front and Back, read under a
+// single lock so that they are consistent with each other. Both will be nil if
+// the map is empty, and both are the same key if it has one key.
+func (m *OrderedMap) Ends() (front, back *Element) {
+	m.RLock()
+	defer m.RUnlock()
+	return newElement(m.ll.Front()), newElement(m.ll.Back())
+}
 
-	var buf = new(bytes.Buffer)
-	enc := gob.NewEncoder(buf)
-	err := enc.Encode(collection)
-	if err != nil {
-		return nil, err
+// Middle returns the element at index Len()/2, or nil if the map is empty. For
+// an even number of keys this is the later of the two middle keys, so for four
+// keys it is the third. It walks half of the list so it is O(n).
+func (m *OrderedMap) Middle() *Element {
+	m.RLock()
+	defer m.RUnlock()
+	element := m.ll.Front()
+	for i := m.ll.Len() / 2; i > 0; i-- {
+		element = element.Next()
 	}
-	return json.Marshal(buf.Bytes())
+
+	return newElement(element)
 }
 
-// unmarshal json to load byte
-func (m *OrderedMap) UnmarshalJSON(data []byte) error {
-	var bys []byte
-	err := json.Unmarshal(data, &bys)
-	if err != nil {
-		return err
+// Validate checks the internal consistency of the map and returns an error
+// describing the first inconsistency found, or nil if there is none. It walks
+// every element so it is intended for use in tests and debugging.
+func (m *OrderedMap) Validate() error {
+	m.RLock()
+	defer m.RUnlock()
+	if m.unordered {
+		return nil
 	}
 
-	var collection []interface{}
-	var buf = bytes.NewReader(bys)
-	dec := gob.NewDecoder(buf)
-	err = dec.Decode(&collection)
-	if err != nil {
-		return err
+	if len(m.kv) != m.ll.Len() {
+		return fmt.Errorf("map has %d keys but list has %d elements",
+			len(m.kv), m.ll.Len())
 	}
 
-	length := len(collection)
-	count := length >> 1
-	if count<<1 != length {
-		return errors.New("invalid data, key-value doesn't match")
-	}
+	// Since the lengths match, if every list element is referenced by its own
+	// key then every key must also reference an element in the list.
+	element := m.ll.Front()
+	for i := 0; element != nil; i++ {
+		key := element.Value.(*orderedMapElement).key
+		mapped, ok := m.kv[key]
+		if !ok {
+			return fmt.Errorf("key %v at index %d is missing from the map",
+				key, i)
+		}
+
+		if mapped != element {
+			return fmt.Errorf("key %v at index %d maps to a different element",
+				key, i)
+		}
 
-	var idx int
-	for i := 0; i < count; i++ {
-		idx = i << 1
-		m.Set(collection[idx], collection[idx+1])
+		element = element.Next()
 	}
 
 	return nil
 }
+
+// SplitAt copies the map into two new maps. head contains all of the elements
+// before key and tail contains key and all of the elements after it, both in
+// the same order. The original map is not modified. If the key does not exist
+// ok will be false and both maps will be nil.
+func (m *OrderedMap) SplitAt(key interface{}) (
+	head, tail *OrderedMap, ok bool) {
+	m.RLock()
+	defer m.RUnlock()
+	mark, ok := m.kv[key]
+	if !ok {
+		return nil, nil, false
+	}
+
+	head, tail = NewOrderedMap(), NewOrderedMap()
+	if m.unordered {
+		element := mark.Value.(*orderedMapElement)
+		tail.set(element.key, element.value)
+
+		return head, tail, true
+	}
+
+	dest := head
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		if e == mark {
+			dest = tail
+		}
+
+		element := e.Value.(*orderedMapElement)
+		dest.set(element.key, element.value)
+	}
+
+	return head, tail, true
+}
+
+// Slice copies the entries at indexes start up to but not including end into a
+// new map, in the same order. The original map is not modified. start and end
+// are clamped to the bounds of the map, and if there are no entries in the
+// range the new map is empty.
+func (m *OrderedMap) Slice(start, end int) *OrderedMap {
+	m.RLock()
+	defer m.RUnlock()
+	if start < 0 {
+		start = 0
+	}
+
+	if end > len(m.kv) {
+		end = len(m.kv)
+	}
+
+	if start >= end {
+		return NewOrderedMap()
+	}
+
+	slice := NewOrderedMapSize(end - start)
+	i := 0
+	m.elements(func(e *list.Element) bool {
+		if i >= end {
+			return false
+		}
+
+		if i >= start {
+			element := e.Value.(*orderedMapElement)
+			slice.set(element.key, element.value)
+		}
+
+		i++
+		return true
+	})
+
+	return slice
+}
+
+// SetBefore sets a value for a key and places it immediately before markKey.
+// If the key already exists it is moved to that position. ErrKeyNotFound is
+// returned if markKey does not exist, or ErrSorted if the map was created with
+// NewSortedMap.
+func (m *OrderedMap) SetBefore(markKey, key, value interface{}) error {
+	value, err := m.validValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	return m.setRelative(markKey, key, value,
+		m.ll.InsertBefore, m.ll.MoveBefore)
+}
+
+// SetAfter sets a value for a key and places it immediately after markKey. If
+// the key already exists it is moved to that position. ErrKeyNotFound is
+// returned if markKey does not exist, or ErrSorted if the map was created with
+// NewSortedMap.
+func (m *OrderedMap) SetAfter(markKey, key, value interface{}) error {
+	value, err := m.validValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	return m.setRelative(markKey, key, value, m.ll.InsertAfter, m.ll.MoveAfter)
+}
+
+func (m *OrderedMap) setRelative(markKey, key, value interface{},
+	insert func(v interface{}, mark *list.Element) *list.Element,
+	move func(e, mark *list.Element)) error {
+	if err := m.checkOrdered(); err != nil {
+		return err
+	}
+
+	mark, ok := m.kv[markKey]
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	if element, ok := m.kv[key]; ok {
+		if element != mark {
+			move(element, mark)
+			m.version++
+		}
+		m.replace(element.Value.(*orderedMapElement), value)
+
+		return nil
+	}
+
+	m.mustCheckKey(key)
+	m.lazyInit()
+	key = m.intern(key)
+	element := &orderedMapElement{key: key, value: value}
+	m.kv[key] = insert(element, mark)
+	m.inserted(element)
+
+	return nil
+}
+
+// Fingerprint returns a hash of the keys and values in order. Maps with the
+// same keys and values in the same order have the same fingerprint, and
+// changing a value or the order of the keys changes it.
+//
+// Each key and value is hashed using its type and its fmt "%v" string, so
+// values that print the same are considered equal. In particular pointers
+// (including nested maps) are hashed by address rather than by what they
+// point to.
+//
+// A map created with WithoutOrdering has no order, so its fingerprint is the
+// sum of a hash of each entry and only changes when the keys or values do.
+func (m *OrderedMap) Fingerprint() uint64 {
+	m.RLock()
+	defer m.RUnlock()
+	if m.unordered {
+		var sum uint64
+		m.elements(func(e *list.Element) bool {
+			h := fnv.New64a()
+			writeFingerprint(h, e.Value.(*orderedMapElement))
+			sum += h.Sum64()
+			return true
+		})
+
+		return sum
+	}
+
+	h := fnv.New64a()
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		writeFingerprint(h, e.Value.(*orderedMapElement))
+	}
+
+	return h.Sum64()
+}
+
+// writeFingerprint writes the key and value of element to h for Fingerprint.
+func writeFingerprint(h hash.Hash64, element *orderedMapElement) {
+	fmt.Fprintf(h, "%T\x00%v\x00%T\x00%v\x00",
+		element.key, element.key, element.value, element.value)
+}
+
+// Compact rebuilds the internal hash map so that memory held for keys that
+// have since been deleted is released. Go maps never shrink, so this is useful
+// for long-lived maps that were once much larger than they are now. See also
+// WithAutoCompact.
+func (m *OrderedMap) Compact() {
+	m.Lock()
+	defer m.Unlock()
+	m.compact()
+}
+
+func (m *OrderedMap) compact() {
+	kv := make(map[interface{}]*list.Element, len(m.kv))
+	m.elements(func(e *list.Element) bool {
+		kv[e.Value.(*orderedMapElement).key] = e
+		return true
+	})
+
+	m.kv = kv
+	m.peak = len(kv)
+}
+
+// Clear removes all of the keys from the map.
+func (m *OrderedMap) Clear() {
+	m.Lock()
+	defer m.Unlock()
+	m.clear()
+}
+
+func (m *OrderedMap) clear() {
+	if m.store != nil {
+		m.clearStore()
+	}
+
+	if m.policy != nil {
+		m.clearPolicy()
+	}
+
+	m.kv = make(map[interface{}]*list.Element)
+	m.ll.Init()
+	m.size = 0
+	m.peak = 0
+	m.version++
+	if m.values != nil {
+		m.values.buckets = nil
+	}
+}
+
+// SetAllOrdered replaces the contents of the map with the entries of data,
+// added in the sequence given by order. Keys in data that are not in order are
+// ignored, and keys in order that are not in data are skipped. If a key
+// appears in order more than once only its first position is used.
+func (m *OrderedMap) SetAllOrdered(data map[interface{}]interface{},
+	order []interface{}) {
+	var keys, values []interface{}
+	for _, key := range order {
+		if value, ok := data[key]; ok {
+			keys = append(keys, key)
+			values = append(values, m.mustValidValue(key, value))
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	m.clear()
+	for i, key := range keys {
+		m.set(key, values[i])
+	}
+}
+
+// ToSyncMap copies every key and value into a new sync.Map, for use with code
+// that expects one. A sync.Map has no order, so the order of the keys is lost.
+// The values are not copied, so values that are pointers, slices or maps are
+// shared with this map.
+func (m *OrderedMap) ToSyncMap() *sync.Map {
+	sm := new(sync.Map)
+	m.ForEach(func(key, value interface{}) bool {
+		sm.Store(key, value)
+		return true
+	})
+
+	return sm
+}
+
+// FromSyncMap sets every key and value from sm as Set does, in the order that
+// sm.Range visits them, which is not specified by sync.Map. The values are not
+// copied.
+func (m *OrderedMap) FromSyncMap(sm *sync.Map) {
+	var keys, values []interface{}
+	sm.Range(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		values = append(values, m.mustValidValue(key, value))
+		return true
+	})
+
+	m.Lock()
+	defer m.Unlock()
+	for i, key := range keys {
+		m.set(key, values[i])
+	}
+}
+
+// MergeFront adds the entries of other to the front of the map, keeping the
+// order they have in other. Keys that already exist have their value replaced
+// but are not moved. The map is locked for the whole merge.
+func (m *OrderedMap) MergeFront(other *OrderedMap) {
+	entries := other.SnapshotEntries()
+	for i, entry := range entries {
+		entries[i].Value = m.mustValidValue(entry.Key, entry.Value)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	var mark *list.Element
+	for _, entry := range entries {
+		if element, ok := m.kv[entry.Key]; ok {
+			m.replace(element.Value.(*orderedMapElement), entry.Value)
+			continue
+		}
+
+		m.mustCheckKey(entry.Key)
+		m.lazyInit()
+		entry.Key = m.intern(entry.Key)
+		element := &orderedMapElement{key: entry.Key, value: entry.Value}
+		if m.less != nil || m.unordered {
+			m.kv[entry.Key] = m.push(element)
+			m.inserted(element)
+			continue
+		}
+
+		// The previously added element may have been evicted.
+		if mark != nil && m.kv[mark.Value.(*orderedMapElement).key] != mark {
+			mark = nil
+		}
+
+		if mark == nil {
+			mark = m.ll.PushFront(element)
+		} else {
+			mark = m.ll.InsertAfter(element, mark)
+		}
+
+		m.kv[entry.Key] = mark
+		m.inserted(element)
+	}
+}
+
+// ForEach calls fn for every key and value from oldest to newest. Iteration
+// stops if fn returns false.
+//
+// The map is read locked while iterating so fn must not modify the map, unless
+// it was created with WithCopyOnWrite. In that case nothing is locked, and fn
+// sees the map as it was when ForEach was called.
+func (m *OrderedMap) ForEach(fn func(key, value interface{}) bool) {
+	if m.copyOnWrite {
+		for _, entry := range m.entries() {
+			if !fn(entry.Key, entry.Value) {
+				return
+			}
+		}
+
+		return
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+	m.elements(func(e *list.Element) bool {
+		element := e.Value.(*orderedMapElement)
+		return fn(element.key, element.value)
+	})
+}
+
+// ForEachMutable calls fn for every key and value from oldest to newest, like
+// ForEach, but fn can ask for the current key to be removed by returning
+// deleteThis as true. Iteration stops if fn returns stop as true, after
+// removing the current key if requested. This is the safe way to filter the
+// map in place.
+//
+// The map is write locked while iterating, so fn must not call methods on the
+// map. Removing the current key through deleteThis is the only change fn can
+// make.
+func (m *OrderedMap) ForEachMutable(
+	fn func(key, value interface{}) (deleteThis, stop bool)) {
+	m.Lock()
+	defer m.Unlock()
+	m.elements(func(e *list.Element) bool {
+		element := e.Value.(*orderedMapElement)
+		deleteThis, stop := fn(element.key, element.value)
+		if deleteThis {
+			m.deleteKey(element.key)
+		}
+
+		return !stop
+	})
+}
+
+// ForEachChunk calls fn with the entries in order, in chunks of size entries,
+// until fn returns false. The last chunk may be smaller. It panics if size is
+// less than 1.
+//
+// The entries are copied with SnapshotEntries before the first call, so every
+// chunk comes from the same consistent copy of the map and no lock is held
+// while fn runs. fn may modify the map, but the changes are not seen by later
+// chunks.
+func (m *OrderedMap) ForEachChunk(size int, fn func(chunk []Entry) bool) {
+	if size < 1 {
+		panic(fmt.Sprintf("orderedmap: invalid chunk size %d", size))
+	}
+
+	entries := m.SnapshotEntries()
+	for len(entries) > 0 {
+		n := size
+		if n > len(entries) {
+			n = len(entries)
+		}
+
+		if !fn(entries[:n:n]) {
+			return
+		}
+
+		entries = entries[n:]
+	}
+}
+
+// ForEachOfType is like ForEach but only calls fn for values that have the
+// same dynamic type as sample. For example, a sample of "" visits only string
+// values. Each value's type is found with reflection, which costs a little
+// more per element than ForEach.
+func (m *OrderedMap) ForEachOfType(sample interface{},
+	fn func(key, value interface{}) bool) {
+	typ := reflect.TypeOf(sample)
+	m.ForEach(func(key, value interface{}) bool {
+		if reflect.TypeOf(value) != typ {
+			return true
+		}
+
+		return fn(key, value)
+	})
+}
+
+// Next returns the key and value that follow key in insertion order. ok will
+// be false if the key does not exist or is the last key.
+func (m *OrderedMap) Next(key interface{}) (
+	nextKey, nextValue interface{}, ok bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.neighbour(key, (*list.Element).Next)
+}
+
+// Prev returns the key and value that precede key in insertion order. ok will
+// be false if the key does not exist or is the first key.
+func (m *OrderedMap) Prev(key interface{}) (
+	prevKey, prevValue interface{}, ok bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.neighbour(key, (*list.Element).Prev)
+}
+
+func (m *OrderedMap) neighbour(key interface{},
+	step func(*list.Element) *list.Element) (interface{}, interface{}, bool) {
+	element, ok := m.kv[key]
+	if !ok {
+		return nil, nil, false
+	}
+
+	element = step(element)
+	if element == nil {
+		return nil, nil, false
+	}
+
+	e := element.Value.(*orderedMapElement)
+
+	return e.key, e.value, true
+}
+
+// CountFunc returns the number of entries for which pred returns true. The map
+// is read locked while counting so pred must not modify the map.
+func (m *OrderedMap) CountFunc(pred func(key, value interface{}) bool) int {
+	count := 0
+	m.ForEach(func(key, value interface{}) bool {
+		if pred(key, value) {
+			count++
+		}
+
+		return true
+	})
+
+	return count
+}
+
+// LongestRun finds the longest sequence of consecutive entries for which pred
+// returns true, and returns the key it starts at and its length. If there is
+// more than one run of that length the first one is returned. If pred is not
+// true for any entry startKey will be nil and length will be 0.
+//
+// The map is read locked while it is walked so pred must not modify the map.
+func (m *OrderedMap) LongestRun(pred func(key, value interface{}) bool) (
+	startKey interface{}, length int) {
+	var runKey interface{}
+	run := 0
+	m.ForEach(func(key, value interface{}) bool {
+		if !pred(key, value) {
+			run = 0
+			return true
+		}
+
+		if run == 0 {
+			runKey = key
+		}
+
+		run++
+		if run > length {
+			startKey, length = runKey, run
+		}
+
+		return true
+	})
+
+	return startKey, length
+}
+
+// GroupBy splits the entries into groups using the key returned by classify.
+// It returns a new map from each group key to an *OrderedMap of the entries in
+// that group. Groups appear in the order they were first seen, and entries
+// keep their order within each group. The original map is not modified.
+func (m *OrderedMap) GroupBy(
+	classify func(key, value interface{}) interface{}) *OrderedMap {
+	groups := NewOrderedMap()
+	m.ForEach(func(key, value interface{}) bool {
+		group := classify(key, value)
+		members, ok := groups.get(group)
+		if !ok {
+			members = NewOrderedMap()
+			groups.set(group, members)
+		}
+
+		members.(*OrderedMap).set(key, value)
+
+		return true
+	})
+
+	return groups
+}
+
+// Version returns a number that changes whenever the contents of the map
+// change, so that callers can cheaply tell whether anything has changed since
+// they last looked. It starts at zero and is incremented when:
+//
+//   - a key is added;
+//   - the value of a key is replaced with a different value;
+//   - a key is removed, including by Delete, PopFront, eviction and
+//     DeleteExpired;
+//   - the map is cleared; or
+//   - a key is moved to a different position.
+//
+// Values are compared with ==, so replacing a value that cannot be compared
+// (such as a slice) always counts as a change. Changing only the TTL of a key
+// is not a change.
+func (m *OrderedMap) Version() uint64 {
+	m.RLock()
+	defer m.RUnlock()
+	return m.version
+}
+
+// EntriesSince returns the entries that were added, or whose value changed,
+// after the map had the given Version, in order. Passing the Version from the
+// last call gives just the entries that need to be synced since then.
+//
+// Removed keys are not reported, and neither are keys that were only moved.
+// Each entry stores the version it was last changed at, which costs 8 bytes
+// per key.
+func (m *OrderedMap) EntriesSince(version uint64) []Entry {
+	m.RLock()
+	defer m.RUnlock()
+	var entries []Entry
+	m.elements(func(element *list.Element) bool {
+		e := element.Value.(*orderedMapElement)
+		if e.version > version {
+			entries = append(entries, Entry{e.key, e.value})
+		}
+
+		return true
+	})
+
+	return entries
+}
+
+// checkKey returns an error if a new key is not accepted by the map.
+func (m *OrderedMap) checkKey(key interface{}) error {
+	if _, ok := key.(string); m.stringKeysOnly && !ok {
+		return ErrNonStringKey
+	}
+
+	return nil
+}
+
+// lazyInit creates kv for a zero OrderedMap value, so that a map declared as
+// &OrderedMap{} or var m OrderedMap works without NewOrderedMap. Every path
+// that adds a key must call it first. The list needs no initialization.
+func (m *OrderedMap) lazyInit() {
+	if m.kv == nil {
+		m.kv = make(map[interface{}]*list.Element)
+	}
+}
+
+// mustCheckKey panics if a new key is not accepted by the map.
+func (m *OrderedMap) mustCheckKey(key interface{}) {
+	if err := m.checkKey(key); err != nil {
+		panic(fmt.Sprintf("orderedmap: %v: %#v", err, key))
+	}
+}
+
+// validValue returns the value to store for key, as returned by the validator
+// if there is one (see WithValueValidator).
+func (m *OrderedMap) validValue(key, value interface{}) (interface{}, error) {
+	if m.validator == nil {
+		return value, nil
+	}
+
+	return m.validator(key, value)
+}
+
+func (m *OrderedMap) mustValidValue(key, value interface{}) interface{} {
+	value, err := m.validValue(key, value)
+	if err != nil {
+		panic(fmt.Sprintf("orderedmap: invalid value for key %#v: %v",
+			key, err))
+	}
+
+	return value
+}
+
+// checkEntries checks the keys and values about to be added by the decoders,
+// replacing each value with the one returned by the validator. The caller must
+// hold the write lock.
+func (m *OrderedMap) checkEntries(keys, values []interface{}) (err error) {
+	for i, key := range keys {
+		err = m.checkKey(key)
+		if err != nil {
+			return err
+		}
+
+		values[i], err = m.validValue(key, values[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MoveToIndex moves an existing key so that it is at index in insertion order,
+// shifting the keys in between. The index is clamped to the range of the map.
+// ErrKeyNotFound is returned if the key does not exist, or ErrSorted if the map
+// was created with NewSortedMap.
+func (m *OrderedMap) MoveToIndex(key interface{}, index int) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.checkOrdered(); err != nil {
+		return err
+	}
+
+	element, ok := m.kv[key]
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	if index < 0 {
+		index = 0
+	}
+
+	if index >= m.ll.Len()-1 {
+		if m.ll.Back() != element {
+			m.ll.MoveToBack(element)
+			m.version++
+		}
+
+		return nil
+	}
+
+	// Find the element that will follow it, not counting itself.
+	mark := m.ll.Front()
+	for i := 0; i < index || mark == element; mark = mark.Next() {
+		if mark != element {
+			i++
+		}
+	}
+
+	if mark.Prev() != element {
+		m.ll.MoveBefore(element, mark)
+		m.version++
+	}
+
+	return nil
+}
+
+// MutateValue replaces the value of an existing key with the result of
+// fn(old) while holding the write lock, so that no other change can happen in
+// between. The key keeps its position and any expiry set with SetWithTTL.
+//
+// It returns false without calling fn if the key does not exist or has
+// expired, or if the map was created with WithAppendOnly. fn must not call
+// other methods on the map.
+func (m *OrderedMap) MutateValue(
+	key interface{}, fn func(v interface{}) interface{}) bool {
+	m.Lock()
+	defer m.Unlock()
+	element, ok := m.kv[key]
+	if !ok || m.appendOnly {
+		return false
+	}
+
+	e := element.Value.(*orderedMapElement)
+	if e.expired() {
+		return false
+	}
+
+	value := m.mustValidValue(key, fn(e.value))
+	expires := e.expires
+	m.replace(e, value)
+	e.expires = expires
+
+	return true
+}
+
+// EqualMap reports whether the map has exactly the keys in order, in that
+// order, and whether the value of each key is equal to its value in data
+// according to reflect.DeepEqual. Keys in data that are not in order are
+// ignored. It is meant for tests, to compare against a map literal without
+// building another OrderedMap.
+func (m *OrderedMap) EqualMap(data map[interface{}]interface{},
+	order []interface{}) bool {
+	keys, values := m.KeysValues()
+	if len(keys) != len(order) {
+		return false
+	}
+
+	for i, key := range keys {
+		if key != order[i] {
+			return false
+		}
+
+		value, ok := data[key]
+		if !ok || !reflect.DeepEqual(values[i], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EqualNumeric reports whether both maps have the same keys in the same order
+// with the same values, treating numbers of different types as equal when
+// they have the same value. It is meant for comparing a map built in code
+// with one that has been through JSON, which decodes all numbers as float64.
+//
+// Keys and values are compared as follows:
+//
+//   - Any two integer or floating point numbers are equal if they are equal
+//     when converted to float64, so 1, int64(1), uint8(1) and 1.0 are equal.
+//     Very large integers may lose precision.
+//   - An *OrderedMap is compared to another *OrderedMap with EqualNumeric.
+//   - A []interface{} is compared element by element with the same rules.
+//   - Anything else, including strings and bools, must be equal according to
+//     reflect.DeepEqual. A number is never equal to a string.
+func (m *OrderedMap) EqualNumeric(other *OrderedMap) bool {
+	keys, values := m.KeysValues()
+	otherKeys, otherValues := other.KeysValues()
+	if len(keys) != len(otherKeys) {
+		return false
+	}
+
+	for i := range keys {
+		if !equalNumeric(keys[i], otherKeys[i]) ||
+			!equalNumeric(values[i], otherValues[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalNumeric(a, b interface{}) bool {
+	if x, ok := toFloat64(a); ok {
+		y, ok := toFloat64(b)
+		return ok && x == y
+	}
+
+	switch a := a.(type) {
+	case *OrderedMap:
+		b, ok := b.(*OrderedMap)
+		return ok && (a == b || a != nil && b != nil && a.EqualNumeric(b))
+
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !equalNumeric(a[i], b[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat64 converts any integer or floating point number to a float64.
+func toFloat64(v interface{}) (float64, bool) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64:
+		return float64(value.Int()), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint()), true
+
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+
+	return 0, false
+}
+
+// Interleave returns a new map that takes one entry from a, then one from b,
+// and so on in order until both are exhausted. Once the shorter map runs out
+// the rest of the longer map follows on. If a key appears in both maps only
+// its first occurrence is kept, with that occurrence's value, and the later
+// one is skipped without taking another entry in its place.
+func Interleave(a, b *OrderedMap) *OrderedMap {
+	aKeys, aValues := a.KeysValues()
+	bKeys, bValues := b.KeysValues()
+	m := NewOrderedMapSize(len(aKeys) + len(bKeys))
+	for i := 0; i < len(aKeys) || i < len(bKeys); i++ {
+		if i < len(aKeys) && !m.Has(aKeys[i]) {
+			m.Set(aKeys[i], aValues[i])
+		}
+
+		if i < len(bKeys) && !m.Has(bKeys[i]) {
+			m.Set(bKeys[i], bValues[i])
+		}
+	}
+
+	return m
+}
+
+// TransformValues replaces each value, in order, with the result of fn. The
+// keys keep their positions and any expiry set with SetWithTTL. It holds the
+// write lock throughout, so fn must not call other methods on the map.
+//
+// If fn returns an error, or the value it returns is rejected by the validator
+// set with WithValueValidator, TransformValues stops and returns it. The values
+// before that key have already been replaced and are left changed, and the
+// value of that key and those after it are unchanged. To change nothing on an
+// error, transform a copy of the entries from SnapshotEntries and apply them
+// afterwards.
+func (m *OrderedMap) TransformValues(
+	fn func(key, value interface{}) (interface{}, error)) error {
+	m.Lock()
+	defer m.Unlock()
+	var err error
+	m.elements(func(e *list.Element) bool {
+		element := e.Value.(*orderedMapElement)
+		var value interface{}
+		value, err = fn(element.key, element.value)
+		if err == nil {
+			value, err = m.validValue(element.key, value)
+		}
+
+		if err != nil {
+			return false
+		}
+
+		expires := element.expires
+		m.replace(element, value)
+		element.expires = expires
+
+		return true
+	})
+
+	return err
+}
+
+// ReorderByKeys rearranges the map so that its keys are in the same order as
+// order, which must contain every key in the map exactly once. Otherwise an
+// error is returned and the map is unchanged: ErrKeyNotFound if order has a key
+// that is not in the map, or a descriptive error if it has too few keys or a
+// repeated key. ErrSorted is returned if the map was created with
+// NewSortedMap.
+func (m *OrderedMap) ReorderByKeys(order []interface{}) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.checkOrdered(); err != nil {
+		return err
+	}
+
+	if len(order) != len(m.kv) {
+		return fmt.Errorf("order has %d keys, map has %d",
+			len(order), len(m.kv))
+	}
+
+	seen := make(map[interface{}]struct{}, len(order))
+	changed := false
+	e := m.ll.Front()
+	for _, key := range order {
+		element, ok := m.kv[key]
+		if !ok {
+			return ErrKeyNotFound
+		}
+
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("key %#v appears more than once in order", key)
+		}
+
+		seen[key] = struct{}{}
+		changed = changed || element != e
+		e = e.Next()
+	}
+
+	if !changed {
+		return nil
+	}
+
+	for _, key := range order {
+		m.ll.MoveToBack(m.kv[key])
+	}
+
+	m.version++
+
+	return nil
+}
+
+// CompactToLatest reorders the map like a compacted log, so that each key is at
+// the position of its latest write rather than its first. Afterwards the keys
+// are in the order in which they were added or last had their value changed,
+// oldest first.
+//
+// Setting a key to the value it already has (as compared for Version) does not
+// count as a write, and neither does moving it. Keys that have not been written
+// since being added stay in their relative order. It does nothing to a map
+// created with NewSortedMap or WithoutOrdering.
+func (m *OrderedMap) CompactToLatest() {
+	m.Lock()
+	defer m.Unlock()
+	if m.checkOrdered() != nil {
+		return
+	}
+
+	elements := make([]*list.Element, 0, len(m.kv))
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		elements = append(elements, e)
+	}
+
+	less := func(i, j int) bool {
+		return elements[i].Value.(*orderedMapElement).version <
+			elements[j].Value.(*orderedMapElement).version
+	}
+
+	if sort.SliceIsSorted(elements, less) {
+		return
+	}
+
+	sort.Slice(elements, less)
+
+	for _, e := range elements {
+		m.ll.MoveToBack(e)
+	}
+
+	m.version++
+}
+
+// Duplicate adds newKey with the same value as srcKey. The new key is added at
+// the back, like Set. The value itself is not copied, so a pointer, slice or
+// map value is shared by both keys.
+//
+// ErrKeyNotFound is returned if srcKey does not exist or has expired, and
+// ErrKeyExists if newKey already exists. Like TrySet, ErrNonStringKey is
+// returned if newKey is not accepted by the map.
+func (m *OrderedMap) Duplicate(srcKey, newKey interface{}) error {
+	m.Lock()
+	defer m.Unlock()
+	value, ok := m.get(srcKey)
+	if !ok {
+		return ErrKeyNotFound
+	}
+
+	if _, ok := m.kv[newKey]; ok {
+		return ErrKeyExists
+	}
+
+	if err := m.checkKey(newKey); err != nil {
+		return err
+	}
+
+	m.set(newKey, value)
+
+	return nil
+}
+
+// SetWithMeta sets a value for a key like Set, and attaches meta to the key,
+// such as a timestamp or where the value came from, which can be read with
+// GetMeta. The metadata stays with the key when its value is replaced by Set or
+// any other method until it is changed with SetWithMeta again, and is removed
+// along with the key. It is not copied by methods that copy entries, such as
+// Slice, and is not encoded as JSON.
+func (m *OrderedMap) SetWithMeta(key, value, meta interface{}) bool {
+	value = m.mustValidValue(key, value)
+	m.Lock()
+	defer m.Unlock()
+	isNew := m.set(key, value)
+
+	// The key may have been evicted straight away.
+	if element, ok := m.kv[key]; ok {
+		element.Value.(*orderedMapElement).meta = &entryMeta{meta}
+	}
+
+	return isNew
+}
+
+// GetMeta returns the metadata attached to key with SetWithMeta. ok is false if
+// the key does not exist, has expired or has no metadata.
+func (m *OrderedMap) GetMeta(key interface{}) (meta interface{}, ok bool) {
+	m.RLock()
+	defer m.RUnlock()
+	element, ok := m.kv[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := element.Value.(*orderedMapElement)
+	if e.expired() || e.meta == nil {
+		return nil, false
+	}
+
+	return e.meta.value, true
+}
+
+// GetAndSet sets the value of key, as Set does, and returns the value it
+// replaced. existed is false, and old is nil, if the key was new (in which
+// case it is added to the back) or had expired. A replaced key keeps its
+// position. This is the equivalent of sync.Map's Swap.
+func (m *OrderedMap) GetAndSet(key, value interface{}) (
+	old interface{}, existed bool) {
+	value = m.mustValidValue(key, value)
+	m.Lock()
+	defer m.Unlock()
+	old, existed = m.get(key)
+	m.set(key, value)
+
+	return old, existed
+}
+
+// CompareAndSwap replaces the value of key with newValue, but only if its
+// current value is equal to oldValue according to eq, or reflect.DeepEqual if
+// eq is nil. It returns true if the value was replaced. The check and the swap
+// happen under the write lock. The key keeps its position and any expiry.
+//
+// It returns false if the key does not exist or has expired, or if the map
+// was created with WithAppendOnly.
+func (m *OrderedMap) CompareAndSwap(key, oldValue, newValue interface{},
+	eq func(a, b interface{}) bool) bool {
+	if eq == nil {
+		eq = reflect.DeepEqual
+	}
+
+	newValue = m.mustValidValue(key, newValue)
+	m.Lock()
+	defer m.Unlock()
+	element, ok := m.kv[key]
+	if !ok || m.appendOnly {
+		return false
+	}
+
+	e := element.Value.(*orderedMapElement)
+	if e.expired() || !eq(e.value, oldValue) {
+		return false
+	}
+
+	expires := e.expires
+	m.replace(e, newValue)
+	e.expires = expires
+
+	return true
+}
+
+// CompareAndDelete deletes key, but only if its current value is equal to
+// oldValue according to eq, or reflect.DeepEqual if eq is nil. It returns true
+// if the key was deleted. It returns false if the key does not exist or has
+// expired.
+func (m *OrderedMap) CompareAndDelete(key, oldValue interface{},
+	eq func(a, b interface{}) bool) bool {
+	if eq == nil {
+		eq = reflect.DeepEqual
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	value, ok := m.get(key)
+	if !ok || !eq(value, oldValue) {
+		return false
+	}
+
+	m.deleteKey(key)
+
+	return true
+}
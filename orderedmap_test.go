@@ -1,11 +1,19 @@
 package orderedmap_test
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"bytes"
+	"encoding/base64"
+	"encoding/gob"
 	"encoding/json"
 	"github.com/abusizhishen/orderedmap"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +24,59 @@ func TestNewOrderedMap(t *testing.T) {
 	assert.IsType(t, &orderedmap.OrderedMap{}, m)
 }
 
+func TestOrderedMap_ZeroValue(t *testing.T) {
+	t.Run("Reads", func(t *testing.T) {
+		m := &orderedmap.OrderedMap{}
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, []interface{}{}, m.Keys())
+		assert.False(t, m.Has("foo"))
+		assert.Nil(t, m.Front())
+		assert.False(t, m.Delete("foo"))
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		var m orderedmap.OrderedMap
+		assert.True(t, m.Set("foo", 1))
+		assert.True(t, m.Set("bar", 2))
+		assert.Equal(t, []interface{}{"foo", "bar"}, m.Keys())
+		assert.Equal(t, 1, m.GetOrDefault("foo", nil))
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("OtherInserts", func(t *testing.T) {
+		m := &orderedmap.OrderedMap{}
+		m.SetWithTTL("a", 1, time.Hour)
+		assert.NoError(t, m.SetAfter("a", "b", 2))
+		m2 := &orderedmap.OrderedMap{}
+		m2.SetKeepingPosition("c", 3)
+		m2.MergeFront(m)
+		assert.Equal(t, []interface{}{"a", "b", "c"}, m2.Keys())
+		assert.NoError(t, m2.Validate())
+	})
+
+	t.Run("UnmarshalJSON", func(t *testing.T) {
+		m := &orderedmap.OrderedMap{}
+		assert.NoError(t, json.Unmarshal([]byte(`{"foo":1}`), m))
+		assert.Equal(t, []interface{}{"foo"}, m.Keys())
+	})
+}
+
+func TestNewOrderedMapSize(t *testing.T) {
+	t.Run("ReturnsEmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapSize(100)
+		assert.IsType(t, &orderedmap.OrderedMap{}, m)
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("CanSetBeyondCapacity", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapSize(1)
+		m.Set(1, true)
+		m.Set(2, true)
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+	})
+}
+
 func TestGet(t *testing.T) {
 	t.Run("ReturnsNotOKIfStringKeyDoesntExist", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
@@ -82,6 +143,81 @@ func TestGet(t *testing.T) {
 	})
 }
 
+func TestOrderedMap_Has(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("foo", nil)
+	assert.True(t, m.Has("foo"))
+	assert.False(t, m.Has("bar"))
+}
+
+func TestOrderedMap_ContainsAll(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("foo", nil)
+	m.Set("bar", 1)
+	assert.True(t, m.ContainsAll())
+	assert.True(t, m.ContainsAll("foo", "bar"))
+	assert.False(t, m.ContainsAll("foo", "baz"))
+	m.SetWithTTL("baz", 2, -time.Second)
+	assert.False(t, m.ContainsAll("baz"))
+}
+
+func TestOrderedMap_ContainsAny(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("foo", nil)
+	assert.False(t, m.ContainsAny())
+	assert.True(t, m.ContainsAny("baz", "foo"))
+	assert.False(t, m.ContainsAny("baz", "qux"))
+	m.SetWithTTL("baz", 2, -time.Second)
+	assert.False(t, m.ContainsAny("baz"))
+}
+
+func TestOrderedMap_ContainsValue(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	assert.False(t, m.ContainsValue("foo", nil))
+
+	m.Set(1, "foo")
+	m.Set(2, []int{1, 2})
+	m.SetWithTTL(3, "bar", -time.Second)
+	assert.True(t, m.ContainsValue("foo", nil))
+	assert.True(t, m.ContainsValue([]int{1, 2}, nil))
+	assert.False(t, m.ContainsValue("bar", nil))
+	assert.False(t, m.ContainsValue("FOO", nil))
+	assert.True(t, m.ContainsValue("FOO", func(a, b interface{}) bool {
+		s, ok := a.(string)
+		return ok && strings.EqualFold(s, b.(string))
+	}))
+}
+
+func TestOrderedMap_GetWithIndex(t *testing.T) {
+	t.Run("KeyDoesntExist", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "bar")
+		value, index, ok := m.GetWithIndex("baz")
+		assert.Nil(t, value)
+		assert.Equal(t, -1, index)
+		assert.False(t, ok)
+	})
+
+	t.Run("ReturnsValueAndIndex", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "bar")
+		m.Set("baz", "qux")
+		value, index, ok := m.GetWithIndex("baz")
+		assert.Equal(t, "qux", value)
+		assert.Equal(t, 1, index)
+		assert.True(t, ok)
+	})
+
+	t.Run("IndexAfterDelete", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "bar")
+		m.Set("baz", "qux")
+		m.Delete("foo")
+		_, index, _ := m.GetWithIndex("baz")
+		assert.Equal(t, 0, index)
+	})
+}
+
 func TestSet(t *testing.T) {
 	t.Run("ReturnsTrueIfStringKeyIsNew", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
@@ -121,271 +257,2652 @@ func TestSet(t *testing.T) {
 			t.Skip("performance test skipped in short mode")
 		}
 
-		res1 := testing.Benchmark(benchmarkOrderedMap_Set(1))
-		res4 := testing.Benchmark(benchmarkOrderedMap_Set(4))
+		res1 := testing.Benchmark(benchmarkOrderedMap_Set(1))
+		res4 := testing.Benchmark(benchmarkOrderedMap_Set(4))
+
+		// O(1) would mean that res4 should take about 4 times longer than res1
+		// because we are doing 4 times the amount of Set operations. Allow for
+		// a wide margin, but not too wide that it would permit the inflection
+		// to O(n^2).
+
+		assert.InDelta(t,
+			4*res1.NsPerOp(), res4.NsPerOp(),
+			2*float64(res1.NsPerOp()))
+	})
+}
+
+func TestOrderedMap_TrySet(t *testing.T) {
+	t.Run("SetsValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.NoError(t, m.TrySet("foo", "bar"))
+		assert.NoError(t, m.TrySet("foo", "baz"))
+		assert.Equal(t, "baz", m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("AppendOnly", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithAppendOnly())
+		assert.NoError(t, m.TrySet("foo", "bar"))
+		assert.Equal(t, orderedmap.ErrKeyExists, m.TrySet("foo", "baz"))
+		assert.Equal(t, "bar", m.GetOrDefault("foo", nil))
+	})
+}
+
+func TestOrderedMap_SetReturningElement(t *testing.T) {
+	t.Run("NewKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		element, isNew := m.SetReturningElement(2, "b")
+		assert.True(t, isNew)
+		assert.Equal(t, 2, element.Key)
+		assert.Equal(t, "b", element.Value)
+		assert.Equal(t, 1, element.Prev().Key)
+		assert.Nil(t, element.Next())
+	})
+
+	t.Run("ExistingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		element, isNew := m.SetReturningElement(2, "B")
+		assert.False(t, isNew)
+		assert.Equal(t, "B", element.Value)
+		assert.Equal(t, 1, element.Prev().Key)
+		assert.Equal(t, 3, element.Next().Key)
+	})
+
+	t.Run("Evicted", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapWithByteCapacity(1,
+			func(key, value interface{}) int { return 2 })
+		element, isNew := m.SetReturningElement(1, "a")
+		assert.True(t, isNew)
+		assert.Nil(t, element)
+	})
+}
+
+func TestWithAppendOnly(t *testing.T) {
+	t.Run("SetDoesntReplace", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithAppendOnly())
+		assert.True(t, m.Set("foo", "bar"))
+		assert.False(t, m.Set("foo", "baz"))
+		assert.Equal(t, "bar", m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("CanDelete", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithAppendOnly())
+		m.Set("foo", "bar")
+		assert.True(t, m.Delete("foo"))
+		assert.True(t, m.Set("foo", "baz"))
+		assert.Equal(t, "baz", m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("UnmarshalKeepsFirst", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithAppendOnly())
+		err := json.Unmarshal([]byte(`{"a":1,"a":2}`), m)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1), m.GetOrDefault("a", nil))
+	})
+}
+
+func TestWithValueValidator(t *testing.T) {
+	errNegative := errors.New("negative")
+	newMap := func(options ...orderedmap.Option) *orderedmap.OrderedMap {
+		return orderedmap.NewOrderedMap(append(options,
+			orderedmap.WithValueValidator(
+				func(key, value interface{}) (interface{}, error) {
+					switch v := value.(type) {
+					case int:
+						if v < 0 {
+							return nil, errNegative
+						}
+
+						if v > 10 {
+							return 10, nil
+						}
+
+					case float64:
+						if v < 0 {
+							return nil, errNegative
+						}
+					}
+
+					return value, nil
+				}))...)
+	}
+
+	t.Run("Set", func(t *testing.T) {
+		m := newMap()
+		m.Set("foo", 5)
+		m.Set("bar", 15)
+		assert.Equal(t, 5, m.GetOrDefault("foo", nil))
+		assert.Equal(t, 10, m.GetOrDefault("bar", nil))
+		assert.Panics(t, func() {
+			m.Set("foo", -1)
+		})
+		assert.Equal(t, 5, m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("TrySet", func(t *testing.T) {
+		m := newMap()
+		assert.NoError(t, m.TrySet("foo", 20))
+		assert.Equal(t, 10, m.GetOrDefault("foo", nil))
+		assert.Equal(t, errNegative, m.TrySet("bar", -1))
+		assert.Equal(t, []interface{}{"foo"}, m.Keys())
+	})
+
+	t.Run("SetBefore", func(t *testing.T) {
+		m := newMap()
+		m.Set("foo", 1)
+		assert.Equal(t, errNegative, m.SetBefore("foo", "bar", -1))
+		assert.Equal(t, []interface{}{"foo"}, m.Keys())
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		m := newMap(orderedmap.WithCapacity(1))
+		m.Set("foo", 1)
+		assert.Error(t, m.TrySet("bar", -1))
+		assert.Equal(t, []interface{}{"foo"}, m.Keys())
+	})
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		m := newMap()
+		m.Set("foo", 1)
+		assert.True(t, m.CompareAndSwap("foo", 1, 20, nil))
+		assert.Equal(t, 10, m.GetOrDefault("foo", nil))
+		assert.Panics(t, func() {
+			m.CompareAndSwap("foo", 10, -1, nil)
+		})
+		assert.Equal(t, 10, m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("MutateValue", func(t *testing.T) {
+		m := newMap()
+		m.Set("foo", 1)
+		assert.True(t, m.MutateValue("foo", func(v interface{}) interface{} {
+			return v.(int) * 20
+		}))
+		assert.Equal(t, 10, m.GetOrDefault("foo", nil))
+		assert.Panics(t, func() {
+			m.MutateValue("foo", func(v interface{}) interface{} {
+				return -v.(int)
+			})
+		})
+		assert.Equal(t, 10, m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("TransformValues", func(t *testing.T) {
+		m := newMap()
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		assert.NoError(t, m.TransformValues(
+			func(key, value interface{}) (interface{}, error) {
+				return value.(int) * 10, nil
+			}))
+		assert.Equal(t, 10, m.GetOrDefault("bar", nil))
+
+		m.Set("foo", 1)
+		assert.Equal(t, errNegative, m.TransformValues(
+			func(key, value interface{}) (interface{}, error) {
+				return -value.(int), nil
+			}))
+		assert.Equal(t, 1, m.GetOrDefault("foo", nil))
+		assert.Equal(t, 10, m.GetOrDefault("bar", nil))
+	})
+
+	t.Run("GetOrCompute", func(t *testing.T) {
+		m := newMap()
+		value, _ := m.GetOrCompute("foo", func() interface{} {
+			return 20
+		})
+		assert.Equal(t, 10, value)
+		assert.Equal(t, 10, m.GetOrDefault("foo", nil))
+		assert.Panics(t, func() {
+			m.GetOrCompute("bar", func() interface{} {
+				return -1
+			})
+		})
+		assert.False(t, m.Has("bar"))
+	})
+
+	t.Run("MergeFront", func(t *testing.T) {
+		m := newMap()
+		m.Set("foo", 1)
+		other := orderedmap.NewOrderedMap()
+		other.Set("bar", 20)
+		m.MergeFront(other)
+		assert.Equal(t, []interface{}{"bar", "foo"}, m.Keys())
+		assert.Equal(t, 10, m.GetOrDefault("bar", nil))
+
+		other.Set("baz", -1)
+		assert.Panics(t, func() {
+			m.MergeFront(other)
+		})
+		assert.Equal(t, []interface{}{"bar", "foo"}, m.Keys())
+	})
+
+	t.Run("SetAllOrdered", func(t *testing.T) {
+		m := newMap()
+		m.Set("foo", 1)
+		m.SetAllOrdered(map[interface{}]interface{}{"bar": 20},
+			[]interface{}{"bar"})
+		assert.Equal(t, 10, m.GetOrDefault("bar", nil))
+		assert.Panics(t, func() {
+			m.SetAllOrdered(map[interface{}]interface{}{"baz": -1},
+				[]interface{}{"baz"})
+		})
+		assert.Equal(t, []interface{}{"bar"}, m.Keys())
+	})
+
+	t.Run("UnmarshalJSON", func(t *testing.T) {
+		m := newMap()
+		assert.Equal(t, errNegative,
+			json.Unmarshal([]byte(`{"foo":1,"bar":-1}`), m))
+		assert.Equal(t, 0, m.Len())
+		assert.NoError(t, json.Unmarshal([]byte(`{"foo":1}`), m))
+		assert.Equal(t, 1.0, m.GetOrDefault("foo", nil))
+	})
+}
+
+func TestWithStringKeysOnly(t *testing.T) {
+	t.Run("AllowsStrings", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithStringKeysOnly())
+		assert.True(t, m.Set("foo", 1))
+		assert.NoError(t, m.TrySet("bar", 2))
+	})
+
+	t.Run("SetPanics", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithStringKeysOnly())
+		assert.Panics(t, func() {
+			m.Set(1, "foo")
+		})
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("TrySetReturnsError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithStringKeysOnly())
+		assert.Equal(t, orderedmap.ErrNonStringKey, m.TrySet(1, "foo"))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("UnmarshalReturnsError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithStringKeysOnly())
+		err := json.Unmarshal([]byte(`[["foo",1],[2,3]]`), m)
+		assert.Equal(t, orderedmap.ErrNonStringKey, err)
+		assert.Equal(t, 0, m.Len())
+	})
+}
+
+func TestLen(t *testing.T) {
+	t.Run("EmptyMapIsZeroLen", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("SingleElementIsLenOne", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(123, true)
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("ThreeElements", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		assert.Equal(t, 3, m.Len())
+	})
+
+	t.Run("Performance", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("performance test skipped in short mode")
+		}
+
+		res1 := testing.Benchmark(benchmarkOrderedMap_Len(1))
+		res4 := testing.Benchmark(benchmarkOrderedMap_Len(4))
+
+		// O(1) would mean that res4 should take about the same time as res1,
+		// because we are accessing the same amount of elements, just on
+		// different sized maps.
+
+		assert.InDelta(t,
+			res1.NsPerOp(), res4.NsPerOp(),
+			0.5*float64(res1.NsPerOp()))
+	})
+}
+
+func TestKeys(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Empty(t, m.Keys())
+	})
+
+	t.Run("OneElement", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		assert.Equal(t, []interface{}{1}, m.Keys())
+	})
+
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		for i := 1; i < 10; i++ {
+			m.Set(i, true)
+		}
+		assert.Equal(t,
+			[]interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9},
+			m.Keys())
+	})
+
+	t.Run("ReplacingKeyDoesntChangeOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", true)
+		m.Set("bar", true)
+		m.Set("foo", false)
+		assert.Equal(t,
+			[]interface{}{"foo", "bar"},
+			m.Keys())
+	})
+
+	t.Run("KeysAfterDelete", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", true)
+		m.Set("bar", true)
+		m.Delete("foo")
+		assert.Equal(t, []interface{}{"bar"}, m.Keys())
+	})
+
+	t.Run("Performance", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("performance test skipped in short mode")
+		}
+
+		res1 := testing.Benchmark(benchmarkOrderedMap_Keys(1))
+		res4 := testing.Benchmark(benchmarkOrderedMap_Keys(4))
+
+		// O(1) would mean that res4 should take about 4 times longer than res1
+		// because we are doing 4 times the amount of Set/Delete operations.
+		// Allow for a wide margin, but not too wide that it would permit the
+		// inflection to O(n^2).
+
+		assert.InDelta(t,
+			4*res1.NsPerOp(), res4.NsPerOp(),
+			float64(res4.NsPerOp()))
+	})
+}
+
+func TestOrderedMap_AppendKeys(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	assert.Nil(t, m.AppendKeys(nil))
+
+	m.Set("foo", 1)
+	m.Set("bar", 2)
+	assert.Equal(t, []interface{}{"baz", "foo", "bar"},
+		m.AppendKeys([]interface{}{"baz"}))
+
+	buf := make([]interface{}, 0, 4)
+	keys := m.AppendKeys(buf)
+	assert.Equal(t, []interface{}{"foo", "bar"}, keys)
+	assert.Equal(t, &buf[:1][0], &keys[0])
+
+	t.Run("CopyOnWrite", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCopyOnWrite())
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		assert.Equal(t, []interface{}{"foo", "bar"}, m.AppendKeys(nil))
+	})
+}
+
+func TestOrderedMap_KeyTypes(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		assert.Nil(t, orderedmap.NewOrderedMap().KeyTypes())
+	})
+
+	t.Run("StringKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		assert.Equal(t, []reflect.Type{reflect.TypeOf("")}, m.KeyTypes())
+	})
+
+	t.Run("MixedKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set("foo", "b")
+		m.Set(2, "c")
+		m.Set(1.5, "d")
+		assert.Equal(t, []reflect.Type{
+			reflect.TypeOf(0), reflect.TypeOf(""), reflect.TypeOf(0.0),
+		}, m.KeyTypes())
+	})
+}
+
+func TestOrderedMap_SortedKeys(t *testing.T) {
+	byLength := func(a, b interface{}) bool {
+		return len(a.(string)) < len(b.(string))
+	}
+
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, []interface{}{}, m.SortedKeys(byLength))
+	})
+
+	t.Run("SortsCopy", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("ccc", 1)
+		m.Set("a", 2)
+		m.Set("bb", 3)
+		m.Set("d", 4)
+		assert.Equal(t, []interface{}{"a", "d", "bb", "ccc"},
+			m.SortedKeys(byLength))
+		assert.Equal(t, []interface{}{"ccc", "a", "bb", "d"}, m.Keys())
+	})
+}
+
+func TestOrderedMap_KeysValues(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		keys, values := m.KeysValues()
+		assert.Empty(t, keys)
+		assert.Empty(t, values)
+	})
+
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		m.Set("baz", 3)
+		m.Set("foo", 4)
+		m.Delete("bar")
+		keys, values := m.KeysValues()
+		assert.Equal(t, []interface{}{"foo", "baz"}, keys)
+		assert.Equal(t, []interface{}{4, 3}, values)
+	})
+
+	t.Run("ConsistentUnderConcurrentWrites", func(t *testing.T) {
+		// Every value is its key negated, so a key and value from different
+		// moments would not match.
+		m := orderedmap.NewOrderedMap()
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					key := w*1000 + i%100
+					if i%3 == 0 {
+						m.Delete(key)
+					} else {
+						m.Set(key, -key)
+					}
+				}
+			}(w)
+		}
+
+		for i := 0; i < 2000; i++ {
+			keys, values := m.KeysValues()
+			if !assert.Equal(t, len(keys), len(values)) {
+				break
+			}
+
+			for j, key := range keys {
+				assert.Equal(t, -key.(int), values[j])
+			}
+		}
+
+		close(done)
+		wg.Wait()
+	})
+}
+
+func TestOrderedMap_SnapshotEntries(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Empty(t, m.SnapshotEntries())
+	})
+
+	t.Run("RetainsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		assert.Equal(t, []orderedmap.Entry{
+			{Key: "foo", Value: 1},
+			{Key: "bar", Value: 2},
+		}, m.SnapshotEntries())
+	})
+
+	t.Run("IsACopy", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		entries := m.SnapshotEntries()
+		m.Set("foo", 2)
+		m.Set("bar", 3)
+		assert.Equal(t, []orderedmap.Entry{{Key: "foo", Value: 1}}, entries)
+	})
+}
+
+func TestOrderedMap_Sample(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	for i := 0; i < 10; i++ {
+		m.Set(i, i*10)
+	}
+
+	t.Run("Zero", func(t *testing.T) {
+		assert.Nil(t, m.Sample(0, nil))
+	})
+
+	t.Run("All", func(t *testing.T) {
+		assert.Equal(t, m.SnapshotEntries(), m.Sample(20, nil))
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		entries := m.Sample(4, nil)
+		assert.Len(t, entries, 4)
+		for i := 1; i < len(entries); i++ {
+			assert.True(t, entries[i-1].Key.(int) < entries[i].Key.(int))
+		}
+	})
+
+	t.Run("Seeded", func(t *testing.T) {
+		assert.Equal(t, m.Sample(3, rand.New(rand.NewSource(1))),
+			m.Sample(3, rand.New(rand.NewSource(1))))
+	})
+
+	t.Run("Unbiased", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		counts := make([]int, 10)
+		for i := 0; i < 10000; i++ {
+			for _, entry := range m.Sample(2, r) {
+				counts[entry.Key.(int)]++
+			}
+		}
+
+		for _, count := range counts {
+			assert.InDelta(t, 2000, count, 200)
+		}
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("KeyDoesntExistReturnsFalse", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.False(t, m.Delete("foo"))
+	})
+
+	t.Run("KeyDoesExist", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", nil)
+		assert.True(t, m.Delete("foo"))
+	})
+
+	t.Run("KeyNoLongerExists", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", nil)
+		m.Delete("foo")
+		_, exists := m.Get("foo")
+		assert.False(t, exists)
+	})
+
+	t.Run("KeyDeleteIsIsolated", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", nil)
+		m.Set("bar", nil)
+		m.Delete("foo")
+		_, exists := m.Get("bar")
+		assert.True(t, exists)
+	})
+
+	t.Run("Performance", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("performance test skipped in short mode")
+		}
+
+		res1 := testing.Benchmark(benchmarkOrderedMap_Delete(1))
+		res4 := testing.Benchmark(benchmarkOrderedMap_Delete(4))
+
+		// O(1) would mean that res4 should take about 4 times longer than res1
+		// because we are doing 4 times the amount of Set/Delete operations.
+		// Allow for a wide margin, but not too wide that it would permit the
+		// inflection to O(n^2).
+
+		assert.InDelta(t,
+			4*res1.NsPerOp(), res4.NsPerOp(),
+			float64(res4.NsPerOp()))
+	})
+}
+
+func TestOrderedMap_SetKeepingPosition(t *testing.T) {
+	t.Run("NewKeyIsAddedToBack", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithPositionHistory(2))
+		m.Set(1, true)
+		m.SetKeepingPosition(2, true)
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+	})
+
+	t.Run("ExistingKeyKeepsPosition", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithPositionHistory(2))
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.SetKeepingPosition(1, "baz")
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+		assert.Equal(t, "baz", m.GetOrDefault(1, nil))
+	})
+
+	t.Run("DeletedKeyIsRestored", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithPositionHistory(2))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		m.Delete(2)
+		m.SetKeepingPosition(2, false)
+		assert.Equal(t, []interface{}{1, 2, 3}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("IndexIsClamped", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithPositionHistory(2))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		m.Delete(3)
+		m.Delete(2)
+		m.SetKeepingPosition(3, true)
+		assert.Equal(t, []interface{}{1, 3}, m.Keys())
+	})
+
+	t.Run("OldestPositionIsForgotten", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithPositionHistory(1))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		m.Delete(1)
+		m.Delete(2)
+		m.SetKeepingPosition(1, true)
+		assert.Equal(t, []interface{}{3, 1}, m.Keys())
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Delete(1)
+		m.SetKeepingPosition(1, true)
+		assert.Equal(t, []interface{}{2, 1}, m.Keys())
+	})
+}
+
+func TestOrderedMap_Retain(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, 0, m.Retain("foo"))
+	})
+
+	t.Run("KeepsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		m.Set(4, "d")
+		assert.Equal(t, 2, m.Retain(4, 2, 5))
+		assert.Equal(t, []interface{}{2, 4}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("NoKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		assert.Equal(t, 2, m.Retain())
+		assert.Equal(t, 0, m.Len())
+	})
+}
+
+func TestOrderedMap_TruncateFrom(t *testing.T) {
+	t.Run("KeyDoesntExist", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		assert.Equal(t, 0, m.TruncateFrom(2))
+		assert.Equal(t, []interface{}{1}, m.Keys())
+	})
+
+	t.Run("Middle", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		m.Set(4, "d")
+		assert.Equal(t, 3, m.TruncateFrom(2))
+		assert.Equal(t, []interface{}{1}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("Back", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		assert.Equal(t, 1, m.TruncateFrom(2))
+		assert.Equal(t, []interface{}{1}, m.Keys())
+	})
+
+	t.Run("Front", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		assert.Equal(t, 2, m.TruncateFrom(1))
+		assert.Equal(t, 0, m.Len())
+	})
+}
+
+func TestOrderedMap_DeleteReturning(t *testing.T) {
+	t.Run("KeyDoesntExist", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		value, existed := m.DeleteReturning("foo")
+		assert.Nil(t, value)
+		assert.False(t, existed)
+	})
+
+	t.Run("ReturnsRemovedValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "bar")
+		m.Set("baz", "qux")
+		value, existed := m.DeleteReturning("foo")
+		assert.Equal(t, "bar", value)
+		assert.True(t, existed)
+		assert.Equal(t, []interface{}{"baz"}, m.Keys())
+	})
+}
+
+func TestOrderedMap_Front(t *testing.T) {
+	t.Run("NilOnEmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Nil(t, m.Front())
+	})
+
+	t.Run("NilOnEmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		assert.NotNil(t, m.Front())
+	})
+}
+
+func TestOrderedMap_Back(t *testing.T) {
+	t.Run("NilOnEmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Nil(t, m.Back())
+	})
+
+	t.Run("NilOnEmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		assert.NotNil(t, m.Back())
+	})
+}
+
+func TestOrderedMap_Ends(t *testing.T) {
+	t.Run("NilOnEmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		front, back := m.Ends()
+		assert.Nil(t, front)
+		assert.Nil(t, back)
+	})
+
+	t.Run("OneKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		front, back := m.Ends()
+		assert.Equal(t, 1, front.Key)
+		assert.Equal(t, 1, back.Key)
+	})
+
+	t.Run("ManyKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		front, back := m.Ends()
+		assert.Equal(t, 1, front.Key)
+		assert.Equal(t, "c", back.Value)
+		assert.Equal(t, 2, front.Next().Key)
+	})
+}
+
+func TestOrderedMap_Middle(t *testing.T) {
+	t.Run("NilOnEmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Nil(t, m.Middle())
+	})
+
+	t.Run("OneKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		assert.Equal(t, 1, m.Middle().Key)
+	})
+
+	t.Run("OddLength", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		for i := 0; i < 5; i++ {
+			m.Set(i, true)
+		}
+		assert.Equal(t, 2, m.Middle().Key)
+	})
+
+	t.Run("EvenLength", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		for i := 0; i < 4; i++ {
+			m.Set(i, true)
+		}
+		element := m.Middle()
+		assert.Equal(t, 2, element.Key)
+		assert.Equal(t, 1, element.Prev().Key)
+	})
+}
+
+func TestOrderedMap_SplitAt(t *testing.T) {
+	t.Run("KeyDoesntExist", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		head, tail, ok := m.SplitAt(2)
+		assert.Nil(t, head)
+		assert.Nil(t, tail)
+		assert.False(t, ok)
+	})
+
+	t.Run("SplitsInOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		m.Set(3, "baz")
+		head, tail, ok := m.SplitAt(2)
+		assert.True(t, ok)
+		assert.Equal(t, []interface{}{1}, head.Keys())
+		assert.Equal(t, []interface{}{2, 3}, tail.Keys())
+		assert.Equal(t, "bar", tail.GetOrDefault(2, nil))
+		assert.Equal(t, []interface{}{1, 2, 3}, m.Keys())
+	})
+
+	t.Run("SplitAtFront", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		head, tail, _ := m.SplitAt(1)
+		assert.Equal(t, 0, head.Len())
+		assert.Equal(t, []interface{}{1, 2}, tail.Keys())
+	})
+}
+
+func TestOrderedMap_Slice(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+	m.Set("d", 4)
+
+	t.Run("Range", func(t *testing.T) {
+		slice := m.Slice(1, 3)
+		assert.Equal(t, []interface{}{"b", "c"}, slice.Keys())
+		assert.Equal(t, 2, slice.GetOrDefault("b", nil))
+		assert.Equal(t, 4, m.Len())
+	})
+
+	t.Run("Clamped", func(t *testing.T) {
+		assert.Equal(t, []interface{}{"a", "b"}, m.Slice(-5, 2).Keys())
+		assert.Equal(t, []interface{}{"c", "d"}, m.Slice(2, 10).Keys())
+		assert.Equal(t, 4, m.Slice(-1, 10).Len())
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.Equal(t, 0, m.Slice(2, 2).Len())
+		assert.Equal(t, 0, m.Slice(3, 1).Len())
+		assert.Equal(t, 0, m.Slice(5, 10).Len())
+		assert.Equal(t, 0, orderedmap.NewOrderedMap().Slice(0, 1).Len())
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		slice := m.Slice(0, 1)
+		slice.Set("a", 10)
+		assert.Equal(t, 1, m.GetOrDefault("a", nil))
+	})
+}
+
+func TestOrderedMap_SetBefore(t *testing.T) {
+	t.Run("MarkKeyDoesntExist", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		assert.Equal(t, orderedmap.ErrKeyNotFound, m.SetBefore(2, 3, true))
+		assert.Equal(t, []interface{}{1}, m.Keys())
+	})
+
+	t.Run("InsertsNewKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set(2, true)
+		assert.NoError(t, m.SetBefore(2, 3, "foo"))
+		assert.Equal(t, []interface{}{1, 3, 2}, m.Keys())
+		assert.Equal(t, "foo", m.GetOrDefault(3, nil))
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("MovesExistingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		assert.NoError(t, m.SetBefore(1, 3, "foo"))
+		assert.Equal(t, []interface{}{3, 1, 2}, m.Keys())
+		assert.Equal(t, "foo", m.GetOrDefault(3, nil))
+	})
+
+	t.Run("SameKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set(2, true)
+		assert.NoError(t, m.SetBefore(2, 2, false))
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+		assert.Equal(t, false, m.GetOrDefault(2, nil))
+	})
+}
+
+func TestOrderedMap_SetAfter(t *testing.T) {
+	t.Run("MarkKeyDoesntExist", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, orderedmap.ErrKeyNotFound, m.SetAfter(2, 3, true))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("InsertsNewKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set(2, true)
+		assert.NoError(t, m.SetAfter(1, 3, "foo"))
+		assert.Equal(t, []interface{}{1, 3, 2}, m.Keys())
+	})
+
+	t.Run("MovesExistingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Set(3, true)
+		assert.NoError(t, m.SetAfter(3, 1, "foo"))
+		assert.Equal(t, []interface{}{2, 3, 1}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+}
+
+func TestOrderedMap_Fingerprint(t *testing.T) {
+	newMap := func(keys ...interface{}) *orderedmap.OrderedMap {
+		m := orderedmap.NewOrderedMap()
+		for _, key := range keys {
+			m.Set(key, true)
+		}
+
+		return m
+	}
+
+	t.Run("SameContents", func(t *testing.T) {
+		assert.Equal(t,
+			newMap(1, "foo", 2).Fingerprint(),
+			newMap(1, "foo", 2).Fingerprint())
+	})
+
+	t.Run("DifferentOrder", func(t *testing.T) {
+		assert.NotEqual(t,
+			newMap(1, "foo", 2).Fingerprint(),
+			newMap(2, "foo", 1).Fingerprint())
+	})
+
+	t.Run("DifferentValue", func(t *testing.T) {
+		m := newMap(1, 2)
+		fingerprint := m.Fingerprint()
+		m.Set(2, false)
+		assert.NotEqual(t, fingerprint, m.Fingerprint())
+	})
+
+	t.Run("DifferentType", func(t *testing.T) {
+		assert.NotEqual(t,
+			newMap(1).Fingerprint(),
+			newMap("1").Fingerprint())
+	})
+}
+
+func TestOrderedMap_Compact(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+	for i := 0; i < 90; i++ {
+		m.Delete(i)
+	}
+
+	m.Compact()
+	assert.NoError(t, m.Validate())
+	assert.Equal(t, []interface{}{90, 91, 92, 93, 94, 95, 96, 97, 98, 99},
+		m.Keys())
+	assert.Equal(t, 95, m.GetOrDefault(95, nil))
+}
+
+func TestWithAutoCompact(t *testing.T) {
+	m := orderedmap.NewOrderedMap(orderedmap.WithAutoCompact(0.5))
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+	for i := 0; i < 99; i++ {
+		m.Delete(i)
+		assert.NoError(t, m.Validate())
+	}
+
+	m.Set(100, 100)
+	assert.Equal(t, []interface{}{99, 100}, m.Keys())
+	assert.NoError(t, m.Validate())
+}
+
+func TestOrderedMap_Clear(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set(1, true)
+	m.Set(2, true)
+	m.Clear()
+	assert.Equal(t, 0, m.Len())
+	assert.Nil(t, m.Front())
+	assert.NoError(t, m.Validate())
+
+	m.Set(3, true)
+	assert.Equal(t, []interface{}{3}, m.Keys())
+}
+
+func TestOrderedMap_SetAllOrdered(t *testing.T) {
+	t.Run("FollowsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetAllOrdered(
+			map[interface{}]interface{}{"a": 1, "b": 2, "c": 3},
+			[]interface{}{"c", "a", "b"})
+		assert.Equal(t, []interface{}{"c", "a", "b"}, m.Keys())
+		assert.Equal(t, 1, m.GetOrDefault("a", nil))
+	})
+
+	t.Run("ReplacesExisting", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("z", true)
+		m.SetAllOrdered(
+			map[interface{}]interface{}{"a": 1},
+			[]interface{}{"a"})
+		assert.Equal(t, []interface{}{"a"}, m.Keys())
+	})
+
+	t.Run("Mismatches", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetAllOrdered(
+			map[interface{}]interface{}{"a": 1, "b": 2},
+			[]interface{}{"x", "b", "a", "b"})
+		assert.Equal(t, []interface{}{"b", "a"}, m.Keys())
+	})
+}
+
+func TestOrderedMap_ToSyncMap(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("a", 1)
+	m.Set(2, []int{3})
+	sm := m.ToSyncMap()
+
+	count := 0
+	sm.Range(func(key, value interface{}) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 2, count)
+
+	value, ok := sm.Load("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+
+	value, _ = sm.Load(2)
+	value.([]int)[0] = 4
+	assert.Equal(t, []int{4}, m.GetOrDefault(2, nil))
+}
+
+func TestOrderedMap_FromSyncMap(t *testing.T) {
+	sm := new(sync.Map)
+	sm.Store("a", 1)
+	sm.Store("b", 2)
+
+	m := orderedmap.NewOrderedMap()
+	m.Set("a", 0)
+	m.Set("c", 3)
+	m.FromSyncMap(sm)
+	assert.Equal(t, 3, m.Len())
+	assert.Equal(t, "a", m.Keys()[0])
+	assert.Equal(t, 1, m.GetOrDefault("a", nil))
+	assert.Equal(t, 2, m.GetOrDefault("b", nil))
+
+	m.FromSyncMap(new(sync.Map))
+	assert.Equal(t, 3, m.Len())
+}
+
+func TestOrderedMap_MergeFront(t *testing.T) {
+	t.Run("InsertsAtFrontInOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		other := orderedmap.NewOrderedMap()
+		other.Set(3, "baz")
+		other.Set(4, "qux")
+		m.MergeFront(other)
+		assert.Equal(t, []interface{}{3, 4, 1, 2}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("ExistingKeysUpdatedInPlace", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		other := orderedmap.NewOrderedMap()
+		other.Set(3, "baz")
+		other.Set(2, "qux")
+		other.Set(4, "quux")
+		m.MergeFront(other)
+		assert.Equal(t, []interface{}{3, 4, 1, 2}, m.Keys())
+		assert.Equal(t, "qux", m.GetOrDefault(2, nil))
+	})
+
+	t.Run("Itself", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.MergeFront(m)
+		assert.Equal(t, []interface{}{1}, m.Keys())
+	})
+
+	t.Run("WithCapacity", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2))
+		m.Set(1, "foo")
+		other := orderedmap.NewOrderedMap()
+		other.Set(2, "bar")
+		other.Set(3, "baz")
+		other.Set(4, "qux")
+		m.MergeFront(other)
+		assert.Equal(t, 2, m.Len())
+		assert.NoError(t, m.Validate())
+	})
+}
+
+func TestOrderedMap_ForEach(t *testing.T) {
+	t.Run("VisitsInOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+
+		var results []interface{}
+		m.ForEach(func(key, value interface{}) bool {
+			results = append(results, key, value)
+			return true
+		})
+
+		assert.Equal(t, []interface{}{1, "foo", 2, "bar"}, results)
+	})
+
+	t.Run("Stops", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+
+		count := 0
+		m.ForEach(func(key, value interface{}) bool {
+			count++
+			return false
+		})
+
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestOrderedMap_ForEachMutable(t *testing.T) {
+	t.Run("Filter", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		for i := 0; i < 6; i++ {
+			m.Set(i, i)
+		}
+
+		var seen []interface{}
+		m.ForEachMutable(func(key, value interface{}) (bool, bool) {
+			seen = append(seen, key)
+			return value.(int)%2 == 0, false
+		})
+		assert.Equal(t, []interface{}{0, 1, 2, 3, 4, 5}, seen)
+		assert.Equal(t, []interface{}{1, 3, 5}, m.Keys())
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("c", 3)
+		m.ForEachMutable(func(key, value interface{}) (bool, bool) {
+			return true, key == "b"
+		})
+		assert.Equal(t, []interface{}{"c"}, m.Keys())
+	})
+
+	t.Run("DeleteAll", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.ForEachMutable(func(key, value interface{}) (bool, bool) {
+			return true, false
+		})
+		assert.Equal(t, 0, m.Len())
+	})
+}
+
+func TestOrderedMap_ForEachChunk(t *testing.T) {
+	newMap := func(n int) *orderedmap.OrderedMap {
+		m := orderedmap.NewOrderedMap()
+		for i := 0; i < n; i++ {
+			m.Set(i, i*10)
+		}
+		return m
+	}
+
+	chunkKeys := func(chunk []orderedmap.Entry) (keys []interface{}) {
+		for _, entry := range chunk {
+			keys = append(keys, entry.Key)
+		}
+		return keys
+	}
+
+	t.Run("EmptyMap", func(t *testing.T) {
+		newMap(0).ForEachChunk(2, func(chunk []orderedmap.Entry) bool {
+			t.Fatal("fn should not be called")
+			return true
+		})
+	})
+
+	t.Run("Chunks", func(t *testing.T) {
+		var chunks [][]interface{}
+		newMap(5).ForEachChunk(2, func(chunk []orderedmap.Entry) bool {
+			chunks = append(chunks, chunkKeys(chunk))
+			return true
+		})
+		assert.Equal(t, [][]interface{}{{0, 1}, {2, 3}, {4}}, chunks)
+	})
+
+	t.Run("Stops", func(t *testing.T) {
+		var chunks [][]interface{}
+		newMap(5).ForEachChunk(2, func(chunk []orderedmap.Entry) bool {
+			chunks = append(chunks, chunkKeys(chunk))
+			return false
+		})
+		assert.Equal(t, [][]interface{}{{0, 1}}, chunks)
+	})
+
+	t.Run("CanModify", func(t *testing.T) {
+		m := newMap(4)
+		var count int
+		m.ForEachChunk(3, func(chunk []orderedmap.Entry) bool {
+			for _, entry := range chunk {
+				m.Delete(entry.Key)
+				count++
+			}
+			return true
+		})
+		assert.Equal(t, 4, count)
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("InvalidSize", func(t *testing.T) {
+		assert.Panics(t, func() {
+			newMap(1).ForEachChunk(0, func([]orderedmap.Entry) bool {
+				return true
+			})
+		})
+	})
+}
+
+func TestOrderedMap_ForEachOfType(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set(1, "foo")
+	m.Set(2, 2)
+	m.Set(3, "bar")
+	m.Set(4, nil)
+
+	var results []interface{}
+	m.ForEachOfType("", func(key, value interface{}) bool {
+		results = append(results, key, value)
+		return true
+	})
+
+	assert.Equal(t, []interface{}{1, "foo", 3, "bar"}, results)
+}
+
+func TestOrderedMap_Next(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set(1, "foo")
+	m.Set(2, "bar")
+
+	t.Run("KeyDoesntExist", func(t *testing.T) {
+		_, _, ok := m.Next(3)
+		assert.False(t, ok)
+	})
+
+	t.Run("ReturnsNext", func(t *testing.T) {
+		key, value, ok := m.Next(1)
+		assert.True(t, ok)
+		assert.Equal(t, 2, key)
+		assert.Equal(t, "bar", value)
+	})
+
+	t.Run("LastKey", func(t *testing.T) {
+		key, value, ok := m.Next(2)
+		assert.False(t, ok)
+		assert.Nil(t, key)
+		assert.Nil(t, value)
+	})
+}
+
+func TestOrderedMap_Prev(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set(1, "foo")
+	m.Set(2, "bar")
+
+	t.Run("KeyDoesntExist", func(t *testing.T) {
+		_, _, ok := m.Prev(3)
+		assert.False(t, ok)
+	})
+
+	t.Run("ReturnsPrev", func(t *testing.T) {
+		key, value, ok := m.Prev(2)
+		assert.True(t, ok)
+		assert.Equal(t, 1, key)
+		assert.Equal(t, "foo", value)
+	})
+
+	t.Run("FirstKey", func(t *testing.T) {
+		_, _, ok := m.Prev(1)
+		assert.False(t, ok)
+	})
+}
+
+func TestOrderedMap_CountFunc(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	for i := 1; i <= 10; i++ {
+		m.Set(i, i*i)
+	}
+
+	assert.Equal(t, 5, m.CountFunc(func(key, value interface{}) bool {
+		return key.(int)%2 == 0
+	}))
+	assert.Equal(t, 0, m.CountFunc(func(key, value interface{}) bool {
+		return value.(int) > 100
+	}))
+}
+
+func TestOrderedMap_LongestRun(t *testing.T) {
+	positive := func(key, value interface{}) bool {
+		return value.(int) > 0
+	}
+
+	t.Run("EmptyMap", func(t *testing.T) {
+		key, length := orderedmap.NewOrderedMap().LongestRun(positive)
+		assert.Nil(t, key)
+		assert.Equal(t, 0, length)
+	})
+
+	t.Run("NoMatches", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", -1)
+		m.Set("b", 0)
+		key, length := m.LongestRun(positive)
+		assert.Nil(t, key)
+		assert.Equal(t, 0, length)
+	})
+
+	t.Run("Runs", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		for i, v := range []int{1, 2, 0, 3, 4, 5, -1, 6, 7, 8, 9} {
+			m.Set(i, v)
+		}
+
+		key, length := m.LongestRun(positive)
+		assert.Equal(t, 7, key)
+		assert.Equal(t, 4, length)
+	})
+
+	t.Run("FirstOfEqualRuns", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		for i, v := range []int{0, 1, 2, 0, 3, 4} {
+			m.Set(i, v)
+		}
+
+		key, length := m.LongestRun(positive)
+		assert.Equal(t, 1, key)
+		assert.Equal(t, 2, length)
+	})
+}
+
+func TestOrderedMap_GroupBy(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("one", 1)
+	m.Set("two", 2)
+	m.Set("three", 3)
+	m.Set("four", 4)
+
+	groups := m.GroupBy(func(key, value interface{}) interface{} {
+		if value.(int)%2 == 0 {
+			return "even"
+		}
+
+		return "odd"
+	})
+
+	assert.Equal(t, []interface{}{"odd", "even"}, groups.Keys())
+	odd := groups.GetOrDefault("odd", nil).(*orderedmap.OrderedMap)
+	assert.Equal(t, []interface{}{"one", "three"}, odd.Keys())
+	even := groups.GetOrDefault("even", nil).(*orderedmap.OrderedMap)
+	assert.Equal(t, []interface{}{"two", "four"}, even.Keys())
+	assert.Equal(t, 4, even.GetOrDefault("four", nil))
+	assert.Equal(t, 4, m.Len())
+}
+
+func TestOrderedMap_Version(t *testing.T) {
+	t.Run("NewMapIsZero", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, uint64(0), m.Version())
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		version := m.Version()
+		changed := func() bool {
+			v := m.Version()
+			defer func() { version = v }()
+			return v != version
+		}
+
+		m.Set("foo", 1)
+		assert.True(t, changed(), "add")
+		m.Set("foo", 2)
+		assert.True(t, changed(), "replace")
+		m.Set("foo", 2)
+		assert.False(t, changed(), "same value")
+		m.Set("foo", []int{1})
+		m.Set("foo", []int{1})
+		assert.True(t, changed(), "uncomparable value")
+		m.Set("bar", 1)
+		assert.NoError(t, m.SetBefore("foo", "bar", 1))
+		assert.True(t, changed(), "move")
+		m.Delete("baz")
+		assert.False(t, changed(), "delete missing key")
+		m.Delete("foo")
+		assert.True(t, changed(), "delete")
+		m.Clear()
+		assert.True(t, changed(), "clear")
+	})
+
+	t.Run("UncomparableInterfaceField", func(t *testing.T) {
+		type wrapper struct{ X interface{} }
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", wrapper{X: []int{1}})
+		version := m.Version()
+		assert.NotPanics(t, func() {
+			m.Set("a", wrapper{X: []int{2}})
+			m.SetWithTTL("a", wrapper{X: []int{3}}, time.Hour)
+			m.MutateValue("a", func(value interface{}) interface{} {
+				return wrapper{X: map[string]int{}}
+			})
+		})
+		assert.True(t, version < m.Version())
+		assert.Equal(t, wrapper{X: map[string]int{}},
+			m.GetOrDefault("a", nil))
+	})
+}
+
+func TestOrderedMap_EntriesSince(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	assert.Nil(t, m.EntriesSince(0))
+
+	m.Set("foo", 1)
+	m.Set("bar", 2)
+	assert.Equal(t, []orderedmap.Entry{{"foo", 1}, {"bar", 2}},
+		m.EntriesSince(0))
+
+	version := m.Version()
+	assert.Nil(t, m.EntriesSince(version))
+
+	m.Set("baz", 3)
+	m.Set("foo", 4)
+	m.Set("bar", 2)
+	m.Delete("baz")
+	assert.NoError(t, m.MoveToIndex("bar", 0))
+	assert.Equal(t, []orderedmap.Entry{{"foo", 4}}, m.EntriesSince(version))
+}
+
+func TestOrderedMap_MoveToIndex(t *testing.T) {
+	newMap := func() *orderedmap.OrderedMap {
+		m := orderedmap.NewOrderedMap()
+		for i := 0; i < 5; i++ {
+			m.Set(i, true)
+		}
+
+		return m
+	}
+
+	t.Run("KeyDoesntExist", func(t *testing.T) {
+		m := newMap()
+		assert.Equal(t, orderedmap.ErrKeyNotFound, m.MoveToIndex(9, 0))
+	})
+
+	for _, test := range []struct {
+		key, index int
+		expected   []interface{}
+	}{
+		{0, 0, []interface{}{0, 1, 2, 3, 4}},
+		{0, 2, []interface{}{1, 2, 0, 3, 4}},
+		{4, 1, []interface{}{0, 4, 1, 2, 3}},
+		{2, 3, []interface{}{0, 1, 3, 2, 4}},
+		{2, 1, []interface{}{0, 2, 1, 3, 4}},
+		{1, 4, []interface{}{0, 2, 3, 4, 1}},
+		{1, 10, []interface{}{0, 2, 3, 4, 1}},
+		{3, -1, []interface{}{3, 0, 1, 2, 4}},
+	} {
+		m := newMap()
+		assert.NoError(t, m.MoveToIndex(test.key, test.index))
+		assert.Equal(t, test.expected, m.Keys(), "%v", test)
+		assert.NoError(t, m.Validate())
+	}
+}
+
+func TestOrderedMap_MutateValue(t *testing.T) {
+	t.Run("ExistingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		ok := m.MutateValue("a", func(v interface{}) interface{} {
+			return v.(int) + 10
+		})
+		assert.True(t, ok)
+		assert.Equal(t, []interface{}{"a", "b"}, m.Keys())
+		value, _ := m.Get("a")
+		assert.Equal(t, 11, value)
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		called := false
+		ok := m.MutateValue("a", func(v interface{}) interface{} {
+			called = true
+			return v
+		})
+		assert.False(t, ok)
+		assert.False(t, called)
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("KeepsTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("a", 1, time.Hour)
+		m.MutateValue("a", func(v interface{}) interface{} {
+			return 2
+		})
+		_, ok := m.TTL("a")
+		assert.True(t, ok)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("a", 1, -time.Second)
+		ok := m.MutateValue("a", func(v interface{}) interface{} {
+			return 2
+		})
+		assert.False(t, ok)
+	})
+
+	t.Run("AppendOnly", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithAppendOnly())
+		m.Set("a", 1)
+		ok := m.MutateValue("a", func(v interface{}) interface{} {
+			return 2
+		})
+		assert.False(t, ok)
+		value, _ := m.Get("a")
+		assert.Equal(t, 1, value)
+	})
+}
+
+func TestOrderedMap_EqualMap(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("a", 1)
+	m.Set(2, []string{"b"})
+	data := map[interface{}]interface{}{"a": 1, 2: []string{"b"}}
+
+	assert.True(t, m.EqualMap(data, []interface{}{"a", 2}))
+	assert.False(t, m.EqualMap(data, []interface{}{2, "a"}))
+	assert.False(t, m.EqualMap(data, []interface{}{"a"}))
+	assert.False(t, m.EqualMap(data, []interface{}{"a", 2, "c"}))
+	assert.False(t, m.EqualMap(map[interface{}]interface{}{"a": 1},
+		[]interface{}{"a", 2}))
+	assert.False(t, m.EqualMap(map[interface{}]interface{}{"a": 1.0,
+		2: []string{"b"}}, []interface{}{"a", 2}))
+	assert.True(t, orderedmap.NewOrderedMap().EqualMap(nil, nil))
+}
+
+func TestOrderedMap_EqualNumeric(t *testing.T) {
+	t.Run("EmptyMaps", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.True(t, m.EqualNumeric(orderedmap.NewOrderedMap()))
+	})
+
+	t.Run("JSONRoundTrip", func(t *testing.T) {
+		nested := orderedmap.NewOrderedMap()
+		nested.Set("x", int8(3))
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", "foo")
+		m.Set("c", true)
+		m.Set("d", []interface{}{uint(2), "bar"})
+		m.Set("e", nested)
+		m.Set("f", nil)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap()
+		assert.NoError(t, json.Unmarshal(b, m2))
+		assert.True(t, m.EqualNumeric(m2))
+		assert.True(t, m2.EqualNumeric(m))
+	})
+
+	t.Run("NumericKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m2 := orderedmap.NewOrderedMap()
+		m2.Set(1.0, "a")
+		assert.True(t, m.EqualNumeric(m2))
+	})
+
+	t.Run("DifferentValues", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m2 := orderedmap.NewOrderedMap()
+		m2.Set("a", 1.5)
+		assert.False(t, m.EqualNumeric(m2))
+	})
+
+	t.Run("NumberAndString", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m2 := orderedmap.NewOrderedMap()
+		m2.Set("a", "1")
+		assert.False(t, m.EqualNumeric(m2))
+	})
+
+	t.Run("DifferentOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m2 := orderedmap.NewOrderedMap()
+		m2.Set("b", 2)
+		m2.Set("a", 1)
+		assert.False(t, m.EqualNumeric(m2))
+	})
+
+	t.Run("DifferentLength", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		assert.False(t, m.EqualNumeric(orderedmap.NewOrderedMap()))
+	})
+}
+
+func TestInterleave(t *testing.T) {
+	t.Run("EmptyMaps", func(t *testing.T) {
+		m := orderedmap.Interleave(orderedmap.NewOrderedMap(),
+			orderedmap.NewOrderedMap())
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("DifferentLengths", func(t *testing.T) {
+		a := orderedmap.NewOrderedMap()
+		a.Set("a1", 1)
+		a.Set("a2", 2)
+		a.Set("a3", 3)
+		b := orderedmap.NewOrderedMap()
+		b.Set("b1", 1)
+		m := orderedmap.Interleave(a, b)
+		assert.Equal(t, []interface{}{"a1", "b1", "a2", "a3"}, m.Keys())
+		assert.Equal(t, []interface{}{"b1", "a1", "a2", "a3"},
+			orderedmap.Interleave(b, a).Keys())
+	})
+
+	t.Run("DuplicateKeys", func(t *testing.T) {
+		a := orderedmap.NewOrderedMap()
+		a.Set(1, "a")
+		a.Set(2, "a")
+		b := orderedmap.NewOrderedMap()
+		b.Set(2, "b")
+		b.Set(1, "b")
+		b.Set(3, "b")
+		m := orderedmap.Interleave(a, b)
+		assert.Equal(t, []interface{}{1, 2, 3}, m.Keys())
+		assert.Equal(t, "a", m.GetOrDefault(1, nil))
+		assert.Equal(t, "b", m.GetOrDefault(2, nil))
+	})
+}
+
+func TestOrderedMap_TransformValues(t *testing.T) {
+	t.Run("AllValues", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", "1")
+		m.Set("b", "2")
+		err := m.TransformValues(func(key, value interface{}) (
+			interface{}, error) {
+			return strconv.Atoi(value.(string))
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", "b"}, m.Keys())
+		assert.Equal(t, 1, m.GetOrDefault("a", nil))
+		assert.Equal(t, 2, m.GetOrDefault("b", nil))
+	})
+
+	t.Run("StopsOnError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", "1")
+		m.Set("b", "x")
+		m.Set("c", "3")
+		err := m.TransformValues(func(key, value interface{}) (
+			interface{}, error) {
+			return strconv.Atoi(value.(string))
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, m.GetOrDefault("a", nil))
+		assert.Equal(t, "x", m.GetOrDefault("b", nil))
+		assert.Equal(t, "3", m.GetOrDefault("c", nil))
+	})
+
+	t.Run("KeepsTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("a", 1, time.Hour)
+		err := m.TransformValues(func(key, value interface{}) (
+			interface{}, error) {
+			return value.(int) + 1, nil
+		})
+		assert.NoError(t, err)
+		_, ok := m.TTL("a")
+		assert.True(t, ok)
+	})
+}
+
+func TestOrderedMap_ReorderByKeys(t *testing.T) {
+	newMap := func() *orderedmap.OrderedMap {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		return m
+	}
+
+	t.Run("Reorders", func(t *testing.T) {
+		m := newMap()
+		assert.NoError(t, m.ReorderByKeys([]interface{}{3, 1, 2}))
+		assert.Equal(t, []interface{}{3, 1, 2}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("SameOrder", func(t *testing.T) {
+		m := newMap()
+		version := m.Version()
+		assert.NoError(t, m.ReorderByKeys([]interface{}{1, 2, 3}))
+		assert.Equal(t, version, m.Version())
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		m := newMap()
+		assert.Error(t, m.ReorderByKeys([]interface{}{3, 1}))
+		assert.Equal(t, []interface{}{1, 2, 3}, m.Keys())
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		m := newMap()
+		assert.Equal(t, orderedmap.ErrKeyNotFound,
+			m.ReorderByKeys([]interface{}{3, 1, 4}))
+		assert.Equal(t, []interface{}{1, 2, 3}, m.Keys())
+	})
+
+	t.Run("RepeatedKey", func(t *testing.T) {
+		m := newMap()
+		assert.Error(t, m.ReorderByKeys([]interface{}{3, 1, 3}))
+		assert.Equal(t, []interface{}{1, 2, 3}, m.Keys())
+	})
+}
+
+func TestOrderedMap_CompactToLatest(t *testing.T) {
+	t.Run("LatestWrite", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 1)
+		m.Set("c", 1)
+		m.Set("a", 2)
+		m.Set("d", 1)
+		m.Set("b", 2)
+		m.Set("c", 1)
+		m.CompactToLatest()
+		assert.Equal(t, []interface{}{"c", "a", "d", "b"}, m.Keys())
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 1)
+		version := m.Version()
+		m.CompactToLatest()
+		assert.Equal(t, []interface{}{"a", "b"}, m.Keys())
+		assert.Equal(t, version, m.Version())
+	})
+
+	t.Run("Moved", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 1)
+		assert.NoError(t, m.MoveToIndex("b", 0))
+		m.CompactToLatest()
+		assert.Equal(t, []interface{}{"a", "b"}, m.Keys())
+	})
+
+	t.Run("Sorted", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(func(a, b interface{}) bool {
+			return a.(string) < b.(string)
+		})
+		m.Set("b", 1)
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.CompactToLatest()
+		assert.Equal(t, []interface{}{"a", "b"}, m.Keys())
+	})
+}
+
+func TestOrderedMap_Duplicate(t *testing.T) {
+	t.Run("CopiesValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		value := []int{1}
+		m.Set("a", value)
+		m.Set("b", 2)
+		assert.NoError(t, m.Duplicate("a", "c"))
+		assert.Equal(t, []interface{}{"a", "b", "c"}, m.Keys())
+		value[0] = 3
+		assert.Equal(t, []int{3}, m.GetOrDefault("c", nil))
+	})
+
+	t.Run("MissingSource", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, orderedmap.ErrKeyNotFound, m.Duplicate("a", "b"))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("ExistingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		assert.Equal(t, orderedmap.ErrKeyExists, m.Duplicate("a", "b"))
+		assert.Equal(t, 2, m.GetOrDefault("b", nil))
+	})
+
+	t.Run("StringKeysOnly", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithStringKeysOnly())
+		m.Set("a", 1)
+		assert.Equal(t, orderedmap.ErrNonStringKey, m.Duplicate("a", 2))
+	})
+}
+
+func TestOrderedMap_SetWithMeta(t *testing.T) {
+	t.Run("NewKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.True(t, m.SetWithMeta("a", 1, "source"))
+		assert.False(t, m.SetWithMeta("a", 2, "other"))
+		assert.Equal(t, 2, m.GetOrDefault("a", nil))
+		meta, ok := m.GetMeta("a")
+		assert.True(t, ok)
+		assert.Equal(t, "other", meta)
+	})
+
+	t.Run("KeptOnUpdate", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithMeta("a", 1, "source")
+		m.Set("a", 2)
+		assert.True(t, m.CompareAndSwap("a", 2, 3, nil))
+		meta, ok := m.GetMeta("a")
+		assert.True(t, ok)
+		assert.Equal(t, "source", meta)
+	})
+
+	t.Run("RemovedWithKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithMeta("a", 1, "source")
+		m.Delete("a")
+		m.Set("a", 2)
+		_, ok := m.GetMeta("a")
+		assert.False(t, ok)
+	})
+
+	t.Run("Evicted", func(t *testing.T) {
+		m := orderedmap.NewOrderedMapWithByteCapacity(1,
+			func(key, value interface{}) int { return 1 })
+		assert.True(t, m.SetWithMeta("a", 1, "source"))
+		_, ok := m.GetMeta("a")
+		assert.False(t, ok)
+	})
+}
+
+func TestOrderedMap_GetMeta(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	_, ok := m.GetMeta("missing")
+	assert.False(t, ok)
+
+	m.Set("a", 1)
+	_, ok = m.GetMeta("a")
+	assert.False(t, ok)
+
+	m.SetWithMeta("b", 2, nil)
+	meta, ok := m.GetMeta("b")
+	assert.True(t, ok)
+	assert.Nil(t, meta)
+}
+
+func TestOrderedMap_GetAndSet(t *testing.T) {
+	t.Run("NewKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		old, existed := m.GetAndSet("bar", 2)
+		assert.Nil(t, old)
+		assert.False(t, existed)
+		assert.Equal(t, []interface{}{"foo", "bar"}, m.Keys())
+	})
+
+	t.Run("ExistingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		old, existed := m.GetAndSet("foo", 3)
+		assert.Equal(t, 1, old)
+		assert.True(t, existed)
+		assert.Equal(t, []interface{}{"foo", "bar"}, m.Keys())
+		assert.Equal(t, 3, m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("ExpiredKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", 1, time.Nanosecond)
+		time.Sleep(time.Millisecond)
+		old, existed := m.GetAndSet("foo", 2)
+		assert.Nil(t, old)
+		assert.False(t, existed)
+		assert.Equal(t, 2, m.GetOrDefault("foo", nil))
+	})
+}
+
+func TestOrderedMap_CompareAndSwap(t *testing.T) {
+	t.Run("Equal", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", []int{1})
+		m.Set("b", 2)
+		assert.True(t, m.CompareAndSwap("a", []int{1}, 3, nil))
+		assert.Equal(t, 3, m.GetOrDefault("a", nil))
+		assert.Equal(t, []interface{}{"a", "b"}, m.Keys())
+	})
+
+	t.Run("NotEqual", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		assert.False(t, m.CompareAndSwap("a", 2, 3, nil))
+		assert.Equal(t, 1, m.GetOrDefault("a", nil))
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.False(t, m.CompareAndSwap("a", nil, 3, nil))
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("CustomEquality", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		always := func(a, b interface{}) bool { return true }
+		assert.True(t, m.CompareAndSwap("a", 2, 3, always))
+		assert.Equal(t, 3, m.GetOrDefault("a", nil))
+	})
+
+	t.Run("KeepsTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("a", 1, time.Hour)
+		assert.True(t, m.CompareAndSwap("a", 1, 2, nil))
+		_, ok := m.TTL("a")
+		assert.True(t, ok)
+	})
+
+	t.Run("AppendOnly", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithAppendOnly())
+		m.Set("a", 1)
+		assert.False(t, m.CompareAndSwap("a", 1, 2, nil))
+		assert.Equal(t, 1, m.GetOrDefault("a", nil))
+	})
+}
+
+func TestOrderedMap_CompareAndDelete(t *testing.T) {
+	t.Run("Equal", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		m.Set("b", 2)
+		assert.True(t, m.CompareAndDelete("a", 1, nil))
+		assert.Equal(t, []interface{}{"b"}, m.Keys())
+	})
+
+	t.Run("NotEqual", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 1)
+		assert.False(t, m.CompareAndDelete("a", 2, nil))
+		assert.Equal(t, []interface{}{"a"}, m.Keys())
+	})
+
+	t.Run("MissingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.False(t, m.CompareAndDelete("a", nil, nil))
+	})
+}
+
+func TestOrderedMap_MarshalJSON(t *testing.T) {
+	t.Run("MarshalJsonEmpty", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{}`, string(b))
+	})
+
+	t.Run("MarshalJsonIntKeyValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, 1)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `[[1,1]]`, string(b))
+	})
+
+	t.Run("MarshalJsonStringKeyValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "boo")
+		m.Set("bar", 1.5)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"foo":"boo","bar":1.5}`, string(b))
+	})
+
+	t.Run("MarshalJsonMixedKeyValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, 1)
+		m.Set("foo", "boo")
+		m.Set("true", true)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `[[1,1],["foo","boo"],["true",true]]`, string(b))
+	})
+
+	t.Run("MarshalJsonStructKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(point{1, 2}, "foo")
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `[[{"X":1,"Y":2},"foo"]]`, string(b))
+	})
+
+	t.Run("MarshalJsonTextMarshalerKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(textKey{"a", "b"}, 1)
+		m.Set("foo", 2)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a-b":1,"foo":2}`, string(b))
+	})
+
+	t.Run("MarshalJsonTextMarshalerKeyCollision", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(textKey{"a", "b"}, 1)
+		m.Set("a-b", 2)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `[["a-b",1],["a-b",2]]`, string(b))
+	})
+
+	t.Run("Performance", func(t *testing.T) {
+	})
+}
+
+func TestOrderedMap_MarshalJSONIndent(t *testing.T) {
+	t.Run("Object", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "boo")
+		m.Set("bar", []int{1})
+		b, err := m.MarshalJSONIndent("", "  ")
+		assert.NoError(t, err)
+		assert.Equal(t, "{\n  \"foo\": \"boo\",\n  \"bar\": [\n    1\n  ]\n}",
+			string(b))
+	})
+
+	t.Run("Pairs", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		b, err := m.MarshalJSONIndent(">", "\t")
+		assert.NoError(t, err)
+		assert.Equal(t, "[\n>\t[\n>\t\t1,\n>\t\ttrue\n>\t]\n>]", string(b))
+	})
+}
+
+func TestOrderedMap_MarshalJSONStringKeys(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		b, err := m.MarshalJSONStringKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, `{}`, string(b))
+	})
+
+	t.Run("MixedKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set("bar", true)
+		m.Set(point{1, 2}, nil)
+		b, err := m.MarshalJSONStringKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"1":"foo","bar":true,"{1 2}":null}`, string(b))
+	})
+
+	t.Run("CollisionsLastWins", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set("bar", true)
+		m.Set("1", "baz")
+		b, err := m.MarshalJSONStringKeys()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"1":"baz","bar":true}`, string(b))
+	})
+}
+
+func TestOrderedMap_UnmarshalJSON(t *testing.T) {
+	t.Run("UnmarshalJsonIntKeyValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 66, 84, 47, 103, 103, 65, 67, 65, 50, 108, 117, 100, 65, 81, 67, 65, 65, 73, 68, 97, 87, 53, 48, 66, 65, 73, 65, 65, 103, 61, 61, 34}
+		err := json.Unmarshal(bys, m)
+		value, ok := m.Get(1)
+		result := nil == err && value == 1 && ok == true
+
+		assert.True(t, result)
+	})
+
+	t.Run("UnmarshalJsonRegisteredGobType", func(t *testing.T) {
+		orderedmap.RegisterGobType(gobValue{})
+		var buf bytes.Buffer
+		err := gob.NewEncoder(&buf).Encode(
+			[]interface{}{"foo", gobValue{Name: "bar"}})
+		assert.NoError(t, err)
+		data, _ := json.Marshal(base64.StdEncoding.EncodeToString(buf.Bytes()))
+
+		m := orderedmap.NewOrderedMap()
+		assert.NoError(t, json.Unmarshal(data, m))
+		assert.Equal(t, gobValue{Name: "bar"}, m.GetOrDefault("foo", nil))
+	})
+
+	t.Run("UnmarshalJsonUnregisteredGobType", func(t *testing.T) {
+		// A gob encoded []interface{}{"k", missing{1}} where missing was
+		// registered under a name that is never registered here.
+		data := `"C38CAQL/gAABEAAAQ/+AAAIGc3RyaW5nDAMAAWsXb3JkZXJlZG1hcF90ZXN0` +
+			`Lm1pc3Npbmf/gQMBAQdtaXNzaW5nAf+CAAEBAQFYAQQAAAAG/4IDAQIA"`
+		m := orderedmap.NewOrderedMap()
+		err := m.UnmarshalJSON([]byte(data))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "RegisterGobType")
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("UnmarshalJsonStringKeyValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 67, 68, 47, 103, 103, 65, 67, 66, 110, 78, 48, 99, 109, 108, 117, 90, 119, 119, 70, 65, 65, 78, 109, 98, 50, 56, 71, 99, 51, 82, 121, 97, 87, 53, 110, 68, 65, 85, 65, 65, 50, 74, 118, 98, 119, 61, 61, 34}
+		err := json.Unmarshal(bys, m)
+		value, ok := m.Get("foo")
+		result := nil == err && value == "boo" && ok == true
+
+		assert.True(t, result)
+	})
+
+	t.Run("UnmarshalJsonMixedKeyValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 69, 106, 47, 103, 103, 65, 71, 65, 50, 108, 117, 100, 65, 81, 67, 65, 65, 73, 68, 97, 87, 53, 48, 66, 65, 73, 65, 65, 103, 90, 122, 100, 72, 74, 112, 98, 109, 99, 77, 66, 81, 65, 68, 90, 109, 57, 118, 66, 110, 78, 48, 99, 109, 108, 117, 90, 119, 119, 70, 65, 65, 78, 105, 98, 50, 56, 71, 99, 51, 82, 121, 97, 87, 53, 110, 68, 65, 89, 65, 66, 72, 82, 121, 100, 87, 85, 69, 89, 109, 57, 118, 98, 65, 73, 67, 65, 65, 69, 61, 34}
+		err := json.Unmarshal(bys, m)
+		result := err == nil
+		keys := []interface{}{1, "foo", "true"}
+		result = result && len(m.Keys()) == len(keys)
+		for idx, key := range m.Keys() {
+			result = result && keys[idx] == key
+		}
+
+		value, ok := m.Get("foo")
+		result = result && value == "boo" && ok == true
+		value, ok = m.Get(1)
+		result = result && value == 1 && ok == true
+		value, ok = m.Get("true")
+		result = result && value == true && ok == true
+
+		assert.True(t, result)
+	})
 
-		// O(1) would mean that res4 should take about 4 times longer than res1
-		// because we are doing 4 times the amount of Set operations. Allow for
-		// a wide margin, but not too wide that it would permit the inflection
-		// to O(n^2).
+	t.Run("UnmarshalJsonObject", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		err := json.Unmarshal([]byte(`{"foo":"boo","bar":1,"baz":null}`), m)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"foo", "bar", "baz"}, m.Keys())
+		assert.Equal(t, float64(1), m.GetOrDefault("bar", nil))
+	})
 
-		assert.InDelta(t,
-			4*res1.NsPerOp(), res4.NsPerOp(),
-			2*float64(res1.NsPerOp()))
+	t.Run("UnmarshalJsonPairs", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		err := json.Unmarshal([]byte(`[[2,"foo"],["bar",true]]`), m)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{float64(2), "bar"}, m.Keys())
+		assert.Equal(t, "foo", m.GetOrDefault(float64(2), nil))
 	})
-}
 
-func TestLen(t *testing.T) {
-	t.Run("EmptyMapIsZeroLen", func(t *testing.T) {
+	t.Run("UnmarshalJsonIntKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithJSONKeyType(0))
+		err := json.Unmarshal([]byte(`[[3,"foo"],[1,"bar"]]`), m)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{3, 1}, m.Keys())
+	})
+
+	t.Run("UnmarshalJsonInvalidPair", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
+		err := json.Unmarshal([]byte(`[[1,"foo"],[2]]`), m)
+		assert.True(t, errors.Is(err, orderedmap.ErrInvalidPairCount))
 		assert.Equal(t, 0, m.Len())
 	})
 
-	t.Run("SingleElementIsLenOne", func(t *testing.T) {
+	t.Run("UnmarshalJsonDecodeError", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		m.Set(123, true)
-		assert.Equal(t, 1, m.Len())
+		err := m.UnmarshalJSON([]byte(`[[1,"foo"],[2]]`))
+		var decodeErr *orderedmap.DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.Equal(t, int64(13), decodeErr.Offset)
+		assert.EqualError(t, err,
+			"invalid data, key-value doesn't match (at offset 13)")
 	})
 
-	t.Run("ThreeElements", func(t *testing.T) {
+	t.Run("UnmarshalJsonSyntaxError", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		m.Set(1, true)
-		m.Set(2, true)
-		m.Set(3, true)
-		assert.Equal(t, 3, m.Len())
+		err := m.UnmarshalJSON([]byte(`{"a":1,}`))
+		var decodeErr *orderedmap.DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		var syntaxErr *json.SyntaxError
+		assert.True(t, errors.As(err, &syntaxErr))
+		assert.Equal(t, syntaxErr.Offset, decodeErr.Offset)
 	})
 
-	t.Run("Performance", func(t *testing.T) {
-		if testing.Short() {
-			t.Skip("performance test skipped in short mode")
-		}
+	t.Run("UnmarshalJsonTrailingData", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		err := m.UnmarshalJSON([]byte(`{"a":1}{"b":2}`))
+		var decodeErr *orderedmap.DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.True(t, errors.Is(err, orderedmap.ErrTrailingData))
+		assert.Equal(t, 0, m.Len())
 
-		res1 := testing.Benchmark(benchmarkOrderedMap_Len(1))
-		res4 := testing.Benchmark(benchmarkOrderedMap_Len(4))
+		err = m.UnmarshalJSON([]byte(`{"a":1} garbage`))
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.Equal(t, 0, m.Len())
 
-		// O(1) would mean that res4 should take about the same time as res1,
-		// because we are accessing the same amount of elements, just on
-		// different sized maps.
+		err = m.UnmarshalJSON([]byte(`null null`))
+		assert.True(t, errors.As(err, &decodeErr))
 
-		assert.InDelta(t,
-			res1.NsPerOp(), res4.NsPerOp(),
-			0.5*float64(res1.NsPerOp()))
+		assert.NoError(t, m.UnmarshalJSON([]byte(`{"a":1}`+"\n")))
+		assert.Equal(t, 1, m.Len())
 	})
-}
 
-func TestKeys(t *testing.T) {
-	t.Run("EmptyMap", func(t *testing.T) {
+	t.Run("UnmarshalJsonNonComparableKey", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		assert.Empty(t, m.Keys())
+		err := json.Unmarshal([]byte(`[[{"X":1},"foo"]]`), m)
+		assert.Error(t, err)
 	})
 
-	t.Run("OneElement", func(t *testing.T) {
+	t.Run("UnmarshalJsonInvalidType", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		m.Set(1, true)
-		assert.Equal(t, []interface{}{1}, m.Keys())
+		err := json.Unmarshal([]byte(`123`), m)
+		assert.Error(t, err)
 	})
 
-	t.Run("RetainsOrder", func(t *testing.T) {
+	t.Run("UnmarshalJsonArrayValue", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		for i := 1; i < 10; i++ {
-			m.Set(i, true)
-		}
+		err := json.Unmarshal([]byte(`{"foo":[1,"bar",[]]}`), m)
+		assert.NoError(t, err)
 		assert.Equal(t,
-			[]interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9},
-			m.Keys())
+			[]interface{}{float64(1), "bar", []interface{}{}},
+			m.GetOrDefault("foo", nil))
 	})
 
-	t.Run("ReplacingKeyDoesntChangeOrder", func(t *testing.T) {
+	t.Run("RoundTripNested", func(t *testing.T) {
+		leaf := orderedmap.NewOrderedMap()
+		leaf.Set("z", 1.5)
+		leaf.Set("a", "foo")
+		middle := orderedmap.NewOrderedMap()
+		middle.Set("y", leaf)
+		middle.Set("b", true)
+		root := orderedmap.NewOrderedMap()
+		root.Set("x", middle)
+		root.Set("c", nil)
+
+		b, err := json.Marshal(root)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"x":{"y":{"z":1.5,"a":"foo"},"b":true},"c":null}`,
+			string(b))
+
 		m := orderedmap.NewOrderedMap()
-		m.Set("foo", true)
-		m.Set("bar", true)
-		m.Set("foo", false)
-		assert.Equal(t,
-			[]interface{}{"foo", "bar"},
-			m.Keys())
+		assert.NoError(t, json.Unmarshal(b, m))
+		assert.Equal(t, []interface{}{"x", "c"}, m.Keys())
+
+		m2 := m.GetOrDefault("x", nil).(*orderedmap.OrderedMap)
+		assert.Equal(t, []interface{}{"y", "b"}, m2.Keys())
+
+		m3 := m2.GetOrDefault("y", nil).(*orderedmap.OrderedMap)
+		assert.Equal(t, []interface{}{"z", "a"}, m3.Keys())
+		assert.Equal(t, 1.5, m3.GetOrDefault("z", nil))
 	})
 
-	t.Run("KeysAfterDelete", func(t *testing.T) {
+	t.Run("UnmarshalJsonKeepsTextualOrder", func(t *testing.T) {
+		data := []byte(`{"k":1,"b":2,"z":3,"a":4,"y":5,` +
+			`"c":6,"x":7,"d":8,"w":9,"e":10}`)
+
+		var expected []interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.Token()
+		for dec.More() {
+			key, _ := dec.Token()
+			expected = append(expected, key)
+			dec.Token()
+		}
+
 		m := orderedmap.NewOrderedMap()
-		m.Set("foo", true)
-		m.Set("bar", true)
-		m.Delete("foo")
-		assert.Equal(t, []interface{}{"bar"}, m.Keys())
+		assert.NoError(t, json.Unmarshal(data, m))
+		assert.Len(t, expected, 10)
+		assert.Equal(t, expected, m.Keys())
 	})
 
-	t.Run("Performance", func(t *testing.T) {
-		if testing.Short() {
-			t.Skip("performance test skipped in short mode")
-		}
+	t.Run("UnmarshalJsonDuplicateKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		err := json.Unmarshal([]byte(`{"a":1,"b":2,"a":3}`), m)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", "b"}, m.Keys())
+		assert.Equal(t, float64(3), m.GetOrDefault("a", nil))
+	})
 
-		res1 := testing.Benchmark(benchmarkOrderedMap_Keys(1))
-		res4 := testing.Benchmark(benchmarkOrderedMap_Keys(4))
+	t.Run("UnmarshalJsonUniqueKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithUniqueJSONKeys())
+		err := json.Unmarshal([]byte(`{"a":1,"b":2,"a":3}`), m)
+		assert.True(t, errors.Is(err, orderedmap.ErrDuplicateKey))
+		assert.Equal(t, 0, m.Len())
 
-		// O(1) would mean that res4 should take about 4 times longer than res1
-		// because we are doing 4 times the amount of Set/Delete operations.
-		// Allow for a wide margin, but not too wide that it would permit the
-		// inflection to O(n^2).
+		err = json.Unmarshal([]byte(`[[1,"a"],[1,"b"]]`), m)
+		assert.True(t, errors.Is(err, orderedmap.ErrDuplicateKey))
 
-		assert.InDelta(t,
-			4*res1.NsPerOp(), res4.NsPerOp(),
-			float64(res4.NsPerOp()))
+		err = json.Unmarshal([]byte(`{"a":{"b":1,"b":2},"b":3}`), m)
+		assert.NoError(t, err)
 	})
-}
 
-func TestDelete(t *testing.T) {
-	t.Run("KeyDoesntExistReturnsFalse", func(t *testing.T) {
+	t.Run("UnmarshalJsonMerge", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		assert.False(t, m.Delete("foo"))
+		m.Set("a", 1)
+		m.Set("b", 2)
+		err := json.Unmarshal([]byte(`{"c":3,"a":4}`), m)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"a", "b", "c"}, m.Keys())
+		assert.Equal(t, float64(4), m.GetOrDefault("a", nil))
 	})
 
-	t.Run("KeyDoesExist", func(t *testing.T) {
-		m := orderedmap.NewOrderedMap()
-		m.Set("foo", nil)
-		assert.True(t, m.Delete("foo"))
+	t.Run("UnmarshalJsonReset", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithUnmarshalReset())
+		m.Set("a", 1)
+		m.Set("b", 2)
+		err := json.Unmarshal([]byte(`{"c":3,"a":4}`), m)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"c", "a"}, m.Keys())
+		assert.Equal(t, float64(4), m.GetOrDefault("a", nil))
+
+		err = json.Unmarshal([]byte(`{"d":`), m)
+		assert.Error(t, err)
+		assert.Equal(t, []interface{}{"c", "a"}, m.Keys())
+
+		assert.NoError(t, json.Unmarshal([]byte(`null`), m))
+		assert.Equal(t, 2, m.Len())
+
+		assert.NoError(t, json.Unmarshal([]byte(`{}`), m))
+		assert.Equal(t, 0, m.Len())
 	})
 
-	t.Run("KeyNoLongerExists", func(t *testing.T) {
+	t.Run("RoundTripIntKeys", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		m.Set("foo", nil)
-		m.Delete("foo")
-		_, exists := m.Get("foo")
-		assert.False(t, exists)
+		m.Set(3, "foo")
+		m.Set(1, "bar")
+		m.Set(2, "baz")
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap(orderedmap.WithJSONKeyType(0))
+		assert.NoError(t, json.Unmarshal(b, m2))
+		assert.Equal(t, m.Keys(), m2.Keys())
+		assert.Equal(t, "bar", m2.GetOrDefault(1, nil))
 	})
 
-	t.Run("KeyDeleteIsIsolated", func(t *testing.T) {
+	t.Run("RoundTripStructKeys", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		m.Set("foo", nil)
-		m.Set("bar", nil)
-		m.Delete("foo")
-		_, exists := m.Get("bar")
-		assert.True(t, exists)
+		m.Set(point{3, 4}, "foo")
+		m.Set(point{1, 2}, "bar")
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap(orderedmap.WithJSONKeyType(point{}))
+		assert.NoError(t, json.Unmarshal(b, m2))
+		assert.Equal(t, []interface{}{point{3, 4}, point{1, 2}}, m2.Keys())
+		assert.Equal(t, "bar", m2.GetOrDefault(point{1, 2}, nil))
 	})
 
-	t.Run("Performance", func(t *testing.T) {
-		if testing.Short() {
-			t.Skip("performance test skipped in short mode")
-		}
+	t.Run("RoundTripTextMarshalerKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(textKey{"c", "d"}, "foo")
+		m.Set(textKey{"a", "b"}, "bar")
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"c-d":"foo","a-b":"bar"}`, string(b))
 
-		res1 := testing.Benchmark(benchmarkOrderedMap_Delete(1))
-		res4 := testing.Benchmark(benchmarkOrderedMap_Delete(4))
+		m2 := orderedmap.NewOrderedMap(orderedmap.WithJSONKeyType(textKey{}))
+		assert.NoError(t, json.Unmarshal(b, m2))
+		assert.Equal(t, []interface{}{textKey{"c", "d"}, textKey{"a", "b"}},
+			m2.Keys())
+	})
 
-		// O(1) would mean that res4 should take about 4 times longer than res1
-		// because we are doing 4 times the amount of Set/Delete operations.
-		// Allow for a wide margin, but not too wide that it would permit the
-		// inflection to O(n^2).
+	t.Run("TextUnmarshalerKeyError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithJSONKeyType(textKey{}))
+		assert.Error(t, json.Unmarshal([]byte(`{"ab":1}`), m))
+		assert.Equal(t, 0, m.Len())
+	})
 
-		assert.InDelta(t,
-			4*res1.NsPerOp(), res4.NsPerOp(),
-			float64(res4.NsPerOp()))
+	t.Run("Performance", func(t *testing.T) {
 	})
 }
 
-func TestOrderedMap_Front(t *testing.T) {
-	t.Run("NilOnEmptyMap", func(t *testing.T) {
-		m := orderedmap.NewOrderedMap()
-		assert.Nil(t, m.Front())
+func TestParseJSON(t *testing.T) {
+	t.Run("Object", func(t *testing.T) {
+		m, err := orderedmap.ParseJSON([]byte(`{"foo":1,"bar":"baz"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"foo", "bar"}, m.Keys())
+		assert.Equal(t, "baz", m.GetOrDefault("bar", nil))
 	})
 
-	t.Run("NilOnEmptyMap", func(t *testing.T) {
-		m := orderedmap.NewOrderedMap()
-		m.Set(1, true)
-		assert.NotNil(t, m.Front())
+	t.Run("WithOptions", func(t *testing.T) {
+		m, err := orderedmap.ParseJSON([]byte(`[[2,"a"],[1,"b"]]`),
+			orderedmap.WithJSONKeyType(0))
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{2, 1}, m.Keys())
 	})
-}
 
-func TestOrderedMap_Back(t *testing.T) {
-	t.Run("NilOnEmptyMap", func(t *testing.T) {
-		m := orderedmap.NewOrderedMap()
-		assert.Nil(t, m.Back())
+	t.Run("Null", func(t *testing.T) {
+		m, err := orderedmap.ParseJSON([]byte(`null`))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, m.Len())
 	})
 
-	t.Run("NilOnEmptyMap", func(t *testing.T) {
-		m := orderedmap.NewOrderedMap()
-		m.Set(1, true)
-		assert.NotNil(t, m.Back())
+	t.Run("InvalidJSON", func(t *testing.T) {
+		m, err := orderedmap.ParseJSON([]byte(`{"foo":`))
+		assert.Error(t, err)
+		assert.Nil(t, m)
+	})
+
+	t.Run("TrailingData", func(t *testing.T) {
+		m, err := orderedmap.ParseJSON([]byte(`{"a":1} garbage`))
+		var decodeErr *orderedmap.DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.Nil(t, m)
 	})
 }
 
-func TestOrderedMap_MarshalJSON(t *testing.T) {
-	t.Run("MarshalJsonIntKeyValue", func(t *testing.T) {
+func TestOrderedMap_EncodeJSON(t *testing.T) {
+	t.Run("MatchesMarshalJSON", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
 		m.Set(1, 1)
-		b, err := json.Marshal(m)
-		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 66, 84, 47, 103, 103, 65, 67, 65, 50, 108, 117, 100, 65, 81, 67, 65, 65, 73, 68, 97, 87, 53, 48, 66, 65, 73, 65, 65, 103, 61, 61, 34}
-		result := bytes.Equal(bys, b) && nil == err
+		m.Set("foo", "boo")
+		m.Set("true", true)
 
-		assert.True(t, result)
+		var buf bytes.Buffer
+		assert.NoError(t, m.EncodeJSON(&buf))
+
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, string(b), buf.String())
 	})
 
-	t.Run("MarshalJsonStringKeyValue", func(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		m.Set("foo", "boo")
-		b, err := json.Marshal(m)
-		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 67, 68, 47, 103, 103, 65, 67, 66, 110, 78, 48, 99, 109, 108, 117, 90, 119, 119, 70, 65, 65, 78, 109, 98, 50, 56, 71, 99, 51, 82, 121, 97, 87, 53, 110, 68, 65, 85, 65, 65, 50, 74, 118, 98, 119, 61, 61, 34}
-		result := bytes.Equal(bys, b) && nil == err
+		m.Set("foo", "bar")
+		m.Set(2, 3)
 
-		assert.True(t, result)
+		var buf bytes.Buffer
+		assert.NoError(t, m.EncodeJSON(&buf))
+
+		m2 := orderedmap.NewOrderedMap()
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), m2))
+		assert.Equal(t, []interface{}{"foo", float64(2)}, m2.Keys())
+		assert.Equal(t, float64(3), m2.GetOrDefault(float64(2), nil))
 	})
+}
 
-	t.Run("MarshalJsonMixedKeyValue", func(t *testing.T) {
+func TestOrderedMap_MarshalCompact(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		m.Set(1, 1)
-		m.Set("foo", "boo")
-		m.Set("true", true)
-		b, err := json.Marshal(m)
-		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 69, 106, 47, 103, 103, 65, 71, 65, 50, 108, 117, 100, 65, 81, 67, 65, 65, 73, 68, 97, 87, 53, 48, 66, 65, 73, 65, 65, 103, 90, 122, 100, 72, 74, 112, 98, 109, 99, 77, 66, 81, 65, 68, 90, 109, 57, 118, 66, 110, 78, 48, 99, 109, 108, 117, 90, 119, 119, 70, 65, 65, 78, 105, 98, 50, 56, 71, 99, 51, 82, 121, 97, 87, 53, 110, 68, 65, 89, 65, 66, 72, 82, 121, 100, 87, 85, 69, 89, 109, 57, 118, 98, 65, 73, 67, 65, 65, 69, 61, 34}
-		result := bytes.Equal(bys, b) && nil == err
+		b, err := m.MarshalCompact()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"values":[],"entries":[]}`, string(b))
+	})
 
-		assert.True(t, result)
+	t.Run("SharedValues", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", "foo")
+		m.Set(2, "bar")
+		m.Set("c", "foo")
+		m.Set("d", 1)
+		m.Set("e", "1")
+		b, err := m.MarshalCompact()
+		assert.NoError(t, err)
+		assert.Equal(t,
+			`{"values":["foo","bar",1,"1"],`+
+				`"entries":[["a",0],[2,1],["c",0],["d",2],["e",3]]}`,
+			string(b))
 	})
 
-	t.Run("Performance", func(t *testing.T) {
+	t.Run("MarshalError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", make(chan int))
+		_, err := m.MarshalCompact()
+		assert.Error(t, err)
 	})
 }
 
-func TestOrderedMap_UnmarshalJSON(t *testing.T) {
-	t.Run("UnmarshalJsonIntKeyValue", func(t *testing.T) {
+func TestOrderedMap_UnmarshalCompact(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 66, 84, 47, 103, 103, 65, 67, 65, 50, 108, 117, 100, 65, 81, 67, 65, 65, 73, 68, 97, 87, 53, 48, 66, 65, 73, 65, 65, 103, 61, 61, 34}
-		err := json.Unmarshal(bys, m)
-		value, ok := m.Get(1)
-		result := nil == err && value == 1 && ok == true
+		m.Set("a", "foo")
+		m.Set(2.0, "bar")
+		m.Set("c", "foo")
+		b, err := m.MarshalCompact()
+		assert.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap()
+		assert.NoError(t, m2.UnmarshalCompact(b))
+		assert.Equal(t, []interface{}{"a", 2.0, "c"}, m2.Keys())
+		value, _ := m2.Get("c")
+		assert.Equal(t, "foo", value)
+	})
 
-		assert.True(t, result)
+	t.Run("SameFormatDifferentJSON", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", []interface{}{1})
+		m.Set("b", []interface{}{"1"})
+		m.Set("c", map[string]interface{}{"x": true})
+		m.Set("d", map[string]interface{}{"x": "true"})
+		m.Set("e", []interface{}{1})
+		b, err := m.MarshalCompact()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"values":[[1],["1"],{"x":true},{"x":"true"}],`+
+			`"entries":[["a",0],["b",1],["c",2],["d",3],["e",0]]}`,
+			string(b))
+
+		m2 := orderedmap.NewOrderedMap()
+		assert.NoError(t, m2.UnmarshalCompact(b))
+		assert.Equal(t, []interface{}{"1"}, m2.GetOrDefault("b", nil))
+		d := m2.GetOrDefault("d", nil).(*orderedmap.OrderedMap)
+		assert.Equal(t, "true", d.GetOrDefault("x", nil))
 	})
 
-	t.Run("UnmarshalJsonStringKeyValue", func(t *testing.T) {
+	t.Run("NestedValuesAreCopied", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 67, 68, 47, 103, 103, 65, 67, 66, 110, 78, 48, 99, 109, 108, 117, 90, 119, 119, 70, 65, 65, 78, 109, 98, 50, 56, 71, 99, 51, 82, 121, 97, 87, 53, 110, 68, 65, 85, 65, 65, 50, 74, 118, 98, 119, 61, 61, 34}
-		err := json.Unmarshal(bys, m)
-		value, ok := m.Get("foo")
-		result := nil == err && value == "boo" && ok == true
+		data := `{"values":[{"x":1}],"entries":[["a",0],["b",0]]}`
+		assert.NoError(t, m.UnmarshalCompact([]byte(data)))
+		a, _ := m.Get("a")
+		a.(*orderedmap.OrderedMap).Set("y", 2)
+		b, _ := m.Get("b")
+		assert.Equal(t, 1, b.(*orderedmap.OrderedMap).Len())
+	})
 
-		assert.True(t, result)
+	t.Run("IndexOutOfRange", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		data := `{"values":["foo"],"entries":[["a",0],["b",1]]}`
+		assert.Error(t, m.UnmarshalCompact([]byte(data)))
+		assert.Equal(t, 0, m.Len())
 	})
 
-	t.Run("UnmarshalJsonMixedKeyValue", func(t *testing.T) {
+	t.Run("InvalidJSON", func(t *testing.T) {
 		m := orderedmap.NewOrderedMap()
-		var bys = []byte{34, 68, 80, 43, 66, 65, 103, 69, 67, 47, 52, 73, 65, 65, 82, 65, 65, 65, 69, 106, 47, 103, 103, 65, 71, 65, 50, 108, 117, 100, 65, 81, 67, 65, 65, 73, 68, 97, 87, 53, 48, 66, 65, 73, 65, 65, 103, 90, 122, 100, 72, 74, 112, 98, 109, 99, 77, 66, 81, 65, 68, 90, 109, 57, 118, 66, 110, 78, 48, 99, 109, 108, 117, 90, 119, 119, 70, 65, 65, 78, 105, 98, 50, 56, 71, 99, 51, 82, 121, 97, 87, 53, 110, 68, 65, 89, 65, 66, 72, 82, 121, 100, 87, 85, 69, 89, 109, 57, 118, 98, 65, 73, 67, 65, 65, 69, 61, 34}
-		err := json.Unmarshal(bys, m)
-		result := err == nil
-		keys := []interface{}{1, "foo", "true"}
-		result = result && len(m.Keys()) == len(keys)
-		for idx, key := range m.Keys() {
-			result = result && keys[idx] == key
-		}
+		assert.Error(t, m.UnmarshalCompact([]byte(`[`)))
+	})
 
-		value, ok := m.Get("foo")
-		result = result && value == "boo" && ok == true
-		value, ok = m.Get(1)
-		result = result && value == 1 && ok == true
-		value, ok = m.Get("true")
-		result = result && value == true && ok == true
+	t.Run("Reset", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithUnmarshalReset())
+		m.Set("a", 1)
+		data := `{"values":["foo"],"entries":[["b",0]]}`
+		assert.NoError(t, m.UnmarshalCompact([]byte(data)))
+		assert.Equal(t, []interface{}{"b"}, m.Keys())
+	})
+}
 
-		assert.True(t, result)
+func TestOrderedMap_Validate(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.NoError(t, m.Validate())
 	})
 
-	t.Run("Performance", func(t *testing.T) {
+	t.Run("AfterSetAndDelete", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, true)
+		m.Set("foo", "bar")
+		m.Set(2, false)
+		m.Set(1, false)
+		m.Delete("foo")
+		assert.NoError(t, m.Validate())
 	})
 }
 
@@ -724,6 +3241,35 @@ func ExampleOrderedMap_Front() {
 	}
 }
 
+type point struct {
+	X, Y int
+}
+
+// gobValue is a value that must be registered to be decoded from gob.
+type gobValue struct {
+	Name string
+}
+
+// textKey is a key that is encoded in JSON as "A-B".
+type textKey struct {
+	A, B string
+}
+
+func (k textKey) MarshalText() ([]byte, error) {
+	return []byte(k.A + "-" + k.B), nil
+}
+
+func (k *textKey) UnmarshalText(text []byte) error {
+	parts := strings.SplitN(string(text), "-", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid textKey %q", text)
+	}
+
+	k.A, k.B = parts[0], parts[1]
+
+	return nil
+}
+
 func nothing(v interface{}) {
 	v = false
 }
@@ -979,3 +3525,25 @@ func BenchmarkAll(b *testing.B) {
 	b.Run("BenchmarkBigOrderedMapString_Iterate", BenchmarkBigOrderedMapString_Iterate)
 	b.Run("BenchmarkBigMapString_Iterate", BenchmarkBigMapString_Iterate)
 }
+
+func BenchmarkOrderedMap_AppendKeys(b *testing.B) {
+	m := orderedmap.NewOrderedMap()
+	for i := 0; i < 100; i++ {
+		m.Set(i, i)
+	}
+
+	b.Run("Keys", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.Keys()
+		}
+	})
+
+	b.Run("AppendKeys", func(b *testing.B) {
+		b.ReportAllocs()
+		var keys []interface{}
+		for i := 0; i < b.N; i++ {
+			keys = m.AppendKeys(keys[:0])
+		}
+	})
+}
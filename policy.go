@@ -0,0 +1,99 @@
+package orderedmap
+
+import "container/list"
+
+// EvictionPolicy chooses which key a map created with WithCapacity or
+// NewOrderedMapWithByteCapacity evicts when it is over its capacity, in place
+// of evicting the oldest key. See WithEvictionPolicy.
+//
+// The map tells the policy about every key that is set, read and removed, and
+// asks it for a victim each time it needs to evict a key. All of the methods
+// are called with the map's write lock held, so a policy does not need its own
+// locking, but it must not call methods on the map. A policy keeps state about
+// the keys of one map, so it must not be shared between maps.
+type EvictionPolicy interface {
+	// OnSet is called after key is added to the map or its value is
+	// replaced, before anything is evicted.
+	OnSet(key interface{})
+
+	// OnGet is called when key is read with Get, GetOrDefault or
+	// GetOrCompute. It is not called by Peek, or for keys that do not exist.
+	OnGet(key interface{})
+
+	// OnDelete is called after key is removed from the map for any reason,
+	// including being evicted.
+	OnDelete(key interface{})
+
+	// Victim returns the key that should be evicted next. The key that was
+	// just set should only be returned when it is the only key left. If ok is
+	// false, or the key is not in the map, the oldest key is evicted instead.
+	Victim() (key interface{}, ok bool)
+}
+
+// listPolicy is an EvictionPolicy that evicts the key at the front of a list,
+// with new keys added to the back.
+type listPolicy struct {
+	ll       list.List
+	elements map[interface{}]*list.Element
+
+	// touch moves a key to the back whenever it is set or read.
+	touch bool
+}
+
+// NewFIFOPolicy returns an EvictionPolicy that evicts the key that was added
+// first, whenever and however often it has been used since.
+func NewFIFOPolicy() EvictionPolicy {
+	return &listPolicy{elements: make(map[interface{}]*list.Element)}
+}
+
+// NewLRUPolicy returns an EvictionPolicy that evicts the least recently used
+// key, where both setting and reading a key counts as using it. Unlike
+// WithGetPromotesRecency, it does not change the order of the map.
+func NewLRUPolicy() EvictionPolicy {
+	return &listPolicy{
+		elements: make(map[interface{}]*list.Element),
+		touch:    true,
+	}
+}
+
+func (p *listPolicy) OnSet(key interface{}) {
+	if e, ok := p.elements[key]; ok {
+		if p.touch {
+			p.ll.MoveToBack(e)
+		}
+
+		return
+	}
+
+	p.elements[key] = p.ll.PushBack(key)
+}
+
+func (p *listPolicy) OnGet(key interface{}) {
+	if e, ok := p.elements[key]; ok && p.touch {
+		p.ll.MoveToBack(e)
+	}
+}
+
+func (p *listPolicy) OnDelete(key interface{}) {
+	if e, ok := p.elements[key]; ok {
+		p.ll.Remove(e)
+		delete(p.elements, key)
+	}
+}
+
+func (p *listPolicy) Victim() (key interface{}, ok bool) {
+	if front := p.ll.Front(); front != nil {
+		return front.Value, true
+	}
+
+	return nil, false
+}
+
+// clearPolicy tells the policy about every key before the map is cleared. The
+// caller must hold the write lock.
+func (m *OrderedMap) clearPolicy() {
+	m.elements(func(e *list.Element) bool {
+		m.policy.OnDelete(e.Value.(*orderedMapElement).key)
+		return true
+	})
+}
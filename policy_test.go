@@ -0,0 +1,121 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+// lastPolicy evicts the most recently set key other than the newest one.
+type lastPolicy struct {
+	keys []interface{}
+}
+
+func (p *lastPolicy) OnSet(key interface{}) {
+	p.OnDelete(key)
+	p.keys = append(p.keys, key)
+}
+
+func (p *lastPolicy) OnGet(key interface{}) {}
+
+func (p *lastPolicy) OnDelete(key interface{}) {
+	for i, k := range p.keys {
+		if k == key {
+			p.keys = append(p.keys[:i], p.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *lastPolicy) Victim() (interface{}, bool) {
+	if len(p.keys) < 2 {
+		return nil, false
+	}
+
+	return p.keys[len(p.keys)-2], true
+}
+
+func TestWithEvictionPolicy(t *testing.T) {
+	t.Run("FIFO", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2),
+			orderedmap.WithEvictionPolicy(orderedmap.NewFIFOPolicy()))
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Get("a")
+		m.Set("a", 3)
+		m.Set("c", 4)
+		assert.Equal(t, []interface{}{"b", "c"}, m.Keys())
+	})
+
+	t.Run("LRU", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2),
+			orderedmap.WithEvictionPolicy(orderedmap.NewLRUPolicy()))
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Get("a")
+		m.Set("c", 3)
+		assert.Equal(t, []interface{}{"a", "c"}, m.Keys())
+
+		m.Set("c", 4)
+		m.Peek("a")
+		m.Set("d", 5)
+		assert.Equal(t, []interface{}{"c", "d"}, m.Keys())
+	})
+
+	t.Run("GetKeepsOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithEvictionPolicy(orderedmap.NewLRUPolicy()))
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("c", 3)
+		m.Get("a")
+		m.GetOrDefault("b", nil)
+		assert.Equal(t, []interface{}{"a", "b", "c"}, m.Keys())
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(2),
+			orderedmap.WithEvictionPolicy(&lastPolicy{}))
+		m.Set("a", 1)
+		m.Set("b", 2)
+		m.Set("c", 3)
+		assert.Equal(t, []interface{}{"a", "c"}, m.Keys())
+
+		m.Delete("c")
+		m.Set("d", 4)
+		m.Set("e", 5)
+		assert.Equal(t, []interface{}{"a", "e"}, m.Keys())
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		policy := orderedmap.NewFIFOPolicy()
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(1),
+			orderedmap.WithEvictionPolicy(policy))
+		m.Set("a", 1)
+		m.Clear()
+		_, ok := policy.Victim()
+		assert.False(t, ok)
+
+		m.Set("b", 2)
+		m.Set("c", 3)
+		assert.Equal(t, []interface{}{"c"}, m.Keys())
+	})
+}
+
+func TestNewLRUPolicy(t *testing.T) {
+	policy := orderedmap.NewLRUPolicy()
+	_, ok := policy.Victim()
+	assert.False(t, ok)
+
+	policy.OnSet("a")
+	policy.OnSet("b")
+	policy.OnGet("a")
+	key, ok := policy.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "b", key)
+
+	policy.OnDelete("b")
+	key, _ = policy.Victim()
+	assert.Equal(t, "a", key)
+}
@@ -0,0 +1,129 @@
+package orderedmap
+
+import (
+	"context"
+	"sync"
+)
+
+// PopFront removes the first (oldest Set) element and returns its key and
+// value. If the map is empty ok will be false.
+func (m *OrderedMap) PopFront() (key, value interface{}, ok bool) {
+	m.Lock()
+	defer m.Unlock()
+	if m.ll.Len() == 0 {
+		return nil, nil, false
+	}
+
+	key, value = m.popFront()
+
+	return key, value, true
+}
+
+// PopFrontIf removes the first element and returns its key and value, but only
+// if pred returns true for it. Otherwise the map is unchanged and ok will be
+// false, as it is when the map is empty. The check and removal happen under
+// the write lock, so pred must not call other methods on the map.
+//
+// For a work queue where keys are added in time order, this processes only
+// the items that are due.
+func (m *OrderedMap) PopFrontIf(pred func(key, value interface{}) bool) (
+	key, value interface{}, ok bool) {
+	m.Lock()
+	defer m.Unlock()
+	front := m.ll.Front()
+	if front == nil {
+		return nil, nil, false
+	}
+
+	element := front.Value.(*orderedMapElement)
+	if !pred(element.key, element.value) {
+		return nil, nil, false
+	}
+
+	key, value = m.popFront()
+
+	return key, value, true
+}
+
+// PopFrontN removes up to n elements from the front and returns them in order.
+// It returns fewer than n entries, possibly none, if the map has fewer than n
+// elements. All of the elements are removed under a single lock, which makes
+// it cheaper than calling PopFront n times when draining a queue in batches.
+func (m *OrderedMap) PopFrontN(n int) []Entry {
+	m.Lock()
+	defer m.Unlock()
+	if n > m.ll.Len() {
+		n = m.ll.Len()
+	}
+
+	if n < 0 {
+		n = 0
+	}
+
+	entries := make([]Entry, n)
+	for i := range entries {
+		entries[i].Key, entries[i].Value = m.popFront()
+	}
+
+	return entries
+}
+
+// PopFrontWait is like PopFront but if the map is empty it blocks until a key
+// is added or ctx is done. If ctx is done before an element becomes available
+// ctx.Err() is returned.
+//
+// Combined with Set this makes the map behave like a FIFO queue where adding a
+// key that is already waiting does not add it again.
+func (m *OrderedMap) PopFrontWait(ctx context.Context) (
+	key, value interface{}, err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.cond == nil {
+		m.cond = sync.NewCond(&m.RWMutex)
+	}
+
+	if m.ll.Len() == 0 {
+		// The condition can't wait on the context directly, so wake all
+		// waiters when it's done. This can only acquire the lock once Wait
+		// has released it, so the wake up cannot be missed.
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctx.Done():
+				m.Lock()
+				m.cond.Broadcast()
+				m.Unlock()
+			case <-stop:
+			}
+		}()
+	}
+
+	for m.ll.Len() == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		m.cond.Wait()
+	}
+
+	key, value = m.popFront()
+
+	return key, value, nil
+}
+
+// popFront removes the front element, which must exist. The caller must hold
+// the write lock.
+func (m *OrderedMap) popFront() (key, value interface{}) {
+	element := m.remove(m.ll.Front())
+
+	return element.key, element.value
+}
+
+// broadcast wakes any goroutines blocked in PopFrontWait.
+func (m *OrderedMap) broadcast() {
+	if m.cond != nil {
+		m.cond.Broadcast()
+	}
+}
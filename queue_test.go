@@ -0,0 +1,126 @@
+package orderedmap_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_PopFront(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		key, value, ok := m.PopFront()
+		assert.Nil(t, key)
+		assert.Nil(t, value)
+		assert.False(t, ok)
+	})
+
+	t.Run("RemovesOldest", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		m.Set(2, "bar")
+		key, value, ok := m.PopFront()
+		assert.Equal(t, 1, key)
+		assert.Equal(t, "foo", value)
+		assert.True(t, ok)
+		assert.Equal(t, []interface{}{2}, m.Keys())
+	})
+}
+
+func TestOrderedMap_PopFrontIf(t *testing.T) {
+	due := func(key, value interface{}) bool {
+		return value.(int) <= 10
+	}
+
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		_, _, ok := m.PopFrontIf(due)
+		assert.False(t, ok)
+	})
+
+	t.Run("Matches", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("a", 5)
+		m.Set("b", 20)
+		key, value, ok := m.PopFrontIf(due)
+		assert.True(t, ok)
+		assert.Equal(t, "a", key)
+		assert.Equal(t, 5, value)
+		assert.Equal(t, []interface{}{"b"}, m.Keys())
+	})
+
+	t.Run("DoesntMatch", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("b", 20)
+		m.Set("a", 5)
+		key, value, ok := m.PopFrontIf(due)
+		assert.False(t, ok)
+		assert.Nil(t, key)
+		assert.Nil(t, value)
+		assert.Equal(t, []interface{}{"b", "a"}, m.Keys())
+	})
+}
+
+func TestOrderedMap_PopFrontN(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("a", 1)
+	m.Set("b", 2)
+	m.Set("c", 3)
+
+	assert.Equal(t, []orderedmap.Entry{}, m.PopFrontN(0))
+	assert.Equal(t, []orderedmap.Entry{}, m.PopFrontN(-1))
+	assert.Equal(t, []orderedmap.Entry{{"a", 1}, {"b", 2}}, m.PopFrontN(2))
+	assert.Equal(t, []interface{}{"c"}, m.Keys())
+	assert.Equal(t, []orderedmap.Entry{{"c", 3}}, m.PopFrontN(5))
+	assert.Equal(t, []orderedmap.Entry{}, m.PopFrontN(1))
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestOrderedMap_PopFrontWait(t *testing.T) {
+	t.Run("ReturnsAvailableElement", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "foo")
+		key, value, err := m.PopFrontWait(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, key)
+		assert.Equal(t, "foo", value)
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("WaitsForSet", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			m.Set(1, "foo")
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		key, value, err := m.PopFrontWait(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, key)
+		assert.Equal(t, "foo", value)
+	})
+
+	t.Run("ReturnsContextError", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		ctx, cancel := context.WithTimeout(context.Background(),
+			10*time.Millisecond)
+		defer cancel()
+		key, value, err := m.PopFrontWait(ctx)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		assert.Nil(t, key)
+		assert.Nil(t, value)
+	})
+
+	t.Run("AlreadyCancelled", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := m.PopFrontWait(ctx)
+		assert.Equal(t, context.Canceled, err)
+	})
+}
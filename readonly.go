@@ -0,0 +1,43 @@
+package orderedmap
+
+// ReadOnlyMap is the subset of OrderedMap methods that do not modify the map.
+// See OrderedMap.ReadOnly.
+type ReadOnlyMap interface {
+	Get(key interface{}) (interface{}, bool)
+	Has(key interface{}) bool
+	Keys() []interface{}
+	Len() int
+	ForEach(fn func(key, value interface{}) bool)
+}
+
+type readOnlyMap struct {
+	m *OrderedMap
+}
+
+// ReadOnly returns a read only wrapper around the map. Reads go through to the
+// map, with the same locking, so they see any changes made to it. The wrapper
+// cannot be converted back to an *OrderedMap, so code given it cannot modify
+// the map.
+func (m *OrderedMap) ReadOnly() ReadOnlyMap {
+	return readOnlyMap{m}
+}
+
+func (r readOnlyMap) Get(key interface{}) (interface{}, bool) {
+	return r.m.Get(key)
+}
+
+func (r readOnlyMap) Has(key interface{}) bool {
+	return r.m.Has(key)
+}
+
+func (r readOnlyMap) Keys() []interface{} {
+	return r.m.Keys()
+}
+
+func (r readOnlyMap) Len() int {
+	return r.m.Len()
+}
+
+func (r readOnlyMap) ForEach(fn func(key, value interface{}) bool) {
+	r.m.ForEach(fn)
+}
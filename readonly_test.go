@@ -0,0 +1,39 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_ReadOnly(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.Set("foo", 1)
+	r := m.ReadOnly()
+
+	t.Run("CannotConvertBack", func(t *testing.T) {
+		_, ok := r.(*orderedmap.OrderedMap)
+		assert.False(t, ok)
+	})
+
+	t.Run("SeesChanges", func(t *testing.T) {
+		m.Set("bar", 2)
+		assert.Equal(t, []interface{}{"foo", "bar"}, r.Keys())
+		assert.Equal(t, 2, r.Len())
+		assert.True(t, r.Has("bar"))
+		assert.False(t, r.Has("baz"))
+		value, ok := r.Get("bar")
+		assert.True(t, ok)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("ForEach", func(t *testing.T) {
+		var keys []interface{}
+		r.ForEach(func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return true
+		})
+		assert.Equal(t, m.Keys(), keys)
+	})
+}
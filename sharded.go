@@ -0,0 +1,192 @@
+package orderedmap
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// ShardedOrderedMap spreads its keys across several OrderedMaps, each with its
+// own lock, so that writes to different keys can happen at the same time. It is
+// meant for write heavy workloads where a single OrderedMap is contended (see
+// WithLockProfiling).
+//
+// Keys are assigned to a shard by a hash of the key, and each shard keeps its
+// own keys in insertion order. There is no single order across shards: Keys
+// merges the shards using a sequence number recorded when each key is added,
+// which is only a best effort when keys are added concurrently.
+type ShardedOrderedMap struct {
+	shards []*OrderedMap
+
+	// seq is the sequence number of the last key added to any shard.
+	seq uint64
+}
+
+// sequenced is the value stored in a shard, along with the sequence number of
+// when its key was added. It is only accessed with the shard locked, and the
+// value is replaced in place so that setting an existing key does not
+// allocate.
+type sequenced struct {
+	seq   uint64
+	value interface{}
+}
+
+// NewShardedOrderedMap creates a map with the given number of shards. It
+// panics if shards is less than 1.
+func NewShardedOrderedMap(shards int) *ShardedOrderedMap {
+	if shards < 1 {
+		panic("orderedmap: NewShardedOrderedMap needs at least 1 shard")
+	}
+
+	m := &ShardedOrderedMap{shards: make([]*OrderedMap, shards)}
+	for i := range m.shards {
+		m.shards[i] = NewOrderedMap()
+	}
+
+	return m
+}
+
+// shard returns the shard that holds key.
+func (m *ShardedOrderedMap) shard(key interface{}) *OrderedMap {
+	return m.shards[hashKey(key)%uint32(len(m.shards))]
+}
+
+// hashKey returns a 32-bit FNV-1a hash of key. Strings and integers are hashed
+// directly, and other keys by their type and fmt "%v" string like Fingerprint.
+func hashKey(key interface{}) uint32 {
+	switch k := key.(type) {
+	case string:
+		return hashString(k)
+	case int:
+		return hashUint64(uint64(k))
+	case int64:
+		return hashUint64(uint64(k))
+	case uint64:
+		return hashUint64(k)
+	}
+
+	return hashString(fmt.Sprintf("%T\x00%v", key, key))
+}
+
+const (
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+func hashString(s string) uint32 {
+	h := uint32(fnvOffset32)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= fnvPrime32
+	}
+
+	return h
+}
+
+func hashUint64(n uint64) uint32 {
+	h := uint32(fnvOffset32)
+	for i := 0; i < 8; i++ {
+		h ^= uint32(n >> (8 * uint(i)) & 0xff)
+		h *= fnvPrime32
+	}
+
+	return h
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *ShardedOrderedMap) Get(key interface{}) (interface{}, bool) {
+	shard := m.shard(key)
+	shard.RLock()
+	defer shard.RUnlock()
+	value, ok := shard.get(key)
+	if !ok {
+		return nil, false
+	}
+
+	return value.(*sequenced).value, true
+}
+
+// Set will set (or replace) a value for a key. If the key was new, then true
+// will be returned. A replaced key keeps its position.
+func (m *ShardedOrderedMap) Set(key, value interface{}) bool {
+	shard := m.shard(key)
+	shard.Lock()
+	defer shard.Unlock()
+	if element, ok := shard.kv[key]; ok {
+		element.Value.(*orderedMapElement).value.(*sequenced).value = value
+		return false
+	}
+
+	return shard.set(key, &sequenced{atomic.AddUint64(&m.seq, 1), value})
+}
+
+// Delete will remove a key from the map. It will return true if the key was
+// removed (the key did exist).
+func (m *ShardedOrderedMap) Delete(key interface{}) bool {
+	return m.shard(key).Delete(key)
+}
+
+// Len returns the number of keys in all of the shards. Each shard is counted
+// separately, so the total may not match any single moment if keys are being
+// added or removed at the same time.
+func (m *ShardedOrderedMap) Len() (n int) {
+	for _, shard := range m.shards {
+		n += shard.Len()
+	}
+
+	return n
+}
+
+// Keys returns the keys of every shard merged into the order they were added,
+// as far as it is known (see ShardedOrderedMap). Each shard is read
+// separately, so changes made at the same time may be partly included.
+func (m *ShardedOrderedMap) Keys() []interface{} {
+	var keys []interface{}
+	var seqs []uint64
+	for _, shard := range m.shards {
+		shard.RLock()
+		shard.elements(func(e *list.Element) bool {
+			element := e.Value.(*orderedMapElement)
+			keys = append(keys, element.key)
+			seqs = append(seqs, element.value.(*sequenced).seq)
+			return true
+		})
+		shard.RUnlock()
+	}
+
+	sort.Sort(bySeq{keys, seqs})
+
+	return keys
+}
+
+// ShardCount returns the number of shards.
+func (m *ShardedOrderedMap) ShardCount() int {
+	return len(m.shards)
+}
+
+// ShardKeys returns the keys of shard i in the order they were added to it. It
+// panics if i is not between 0 and ShardCount()-1.
+func (m *ShardedOrderedMap) ShardKeys(i int) []interface{} {
+	return m.shards[i].Keys()
+}
+
+// bySeq sorts keys by their sequence numbers.
+type bySeq struct {
+	keys []interface{}
+	seqs []uint64
+}
+
+func (s bySeq) Len() int {
+	return len(s.keys)
+}
+
+func (s bySeq) Less(i, j int) bool {
+	return s.seqs[i] < s.seqs[j]
+}
+
+func (s bySeq) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.seqs[i], s.seqs[j] = s.seqs[j], s.seqs[i]
+}
@@ -0,0 +1,113 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewShardedOrderedMap(t *testing.T) {
+	m := orderedmap.NewShardedOrderedMap(4)
+	assert.Equal(t, 4, m.ShardCount())
+	assert.Equal(t, 0, m.Len())
+	assert.Nil(t, m.Keys())
+
+	assert.Panics(t, func() {
+		orderedmap.NewShardedOrderedMap(0)
+	})
+}
+
+func TestShardedOrderedMap(t *testing.T) {
+	t.Run("GetSetDelete", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap(4)
+		assert.True(t, m.Set("a", 1))
+		assert.True(t, m.Set(2, "b"))
+		assert.False(t, m.Set("a", 3))
+
+		value, ok := m.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 3, value)
+
+		_, ok = m.Get("missing")
+		assert.False(t, ok)
+
+		assert.True(t, m.Delete("a"))
+		assert.False(t, m.Delete("a"))
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("Keys", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap(3)
+		var expected []interface{}
+		for i := 0; i < 20; i++ {
+			m.Set(i, i)
+			expected = append(expected, i)
+		}
+
+		m.Set(0, "replaced")
+		m.Delete(10)
+		expected = append(expected[:10], expected[11:]...)
+		assert.Equal(t, expected, m.Keys())
+	})
+
+	t.Run("ShardKeys", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap(3)
+		for i := 0; i < 20; i++ {
+			m.Set(i, i)
+		}
+
+		var all []interface{}
+		for i := 0; i < m.ShardCount(); i++ {
+			keys := m.ShardKeys(i)
+			for j := 1; j < len(keys); j++ {
+				assert.True(t, keys[j-1].(int) < keys[j].(int))
+			}
+
+			all = append(all, keys...)
+		}
+		assert.Len(t, all, 20)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		m := orderedmap.NewShardedOrderedMap(8)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					key := fmt.Sprintf("%d-%d", i, j)
+					m.Set(key, j)
+					m.Get(key)
+				}
+			}(i)
+		}
+
+		wg.Wait()
+		assert.Equal(t, 800, m.Len())
+		assert.Len(t, m.Keys(), 800)
+	})
+}
+
+func BenchmarkShardedOrderedMap_Set(b *testing.B) {
+	b.Run("OrderedMap", func(b *testing.B) {
+		m := orderedmap.NewOrderedMap()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				m.Set(i%1000, i)
+			}
+		})
+	})
+
+	b.Run("ShardedOrderedMap", func(b *testing.B) {
+		m := orderedmap.NewShardedOrderedMap(16)
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				m.Set(i%1000, i)
+			}
+		})
+	})
+}
@@ -0,0 +1,219 @@
+package orderedmap
+
+import (
+	"bufio"
+	"bytes"
+	"sync"
+)
+
+// SmallOrderedMap is an ordered map for a handful of keys. It stores its
+// entries in a slice and finds keys with a linear search, which avoids the
+// cost of hashing and of allocating a list element for each key. It is safe
+// for concurrent use.
+//
+// Lookups are O(n), so it is only faster than OrderedMap for small maps. On a
+// typical 64-bit machine Get is faster up to around 10 keys, and building the
+// map with Set is around twice as fast even at 32 keys because it allocates
+// much less. For maps that are read far more than they are written, the
+// crossover is about 10 keys; above that use OrderedMap. Run
+// BenchmarkSmallOrderedMap to check on your own hardware.
+//
+// SmallOrderedMap implements ReadOnlyMap, along with Set, GetOrDefault,
+// Delete, Front, Back, MarshalJSON and UnmarshalJSON. It does not support the
+// other options and methods of OrderedMap.
+type SmallOrderedMap struct {
+	entries []Entry
+	sync.RWMutex
+}
+
+// NewSmallOrderedMap creates an empty SmallOrderedMap.
+func NewSmallOrderedMap() *SmallOrderedMap {
+	return &SmallOrderedMap{}
+}
+
+// indexOf returns the index of key in entries, or -1 if it does not exist. The
+// caller must hold the lock.
+func (m *SmallOrderedMap) indexOf(key interface{}) int {
+	for i := range m.entries {
+		if m.entries[i].Key == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Get returns the value for a key. If the key does not exist, the second return
+// parameter will be false and the value will be nil.
+func (m *SmallOrderedMap) Get(key interface{}) (interface{}, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	if i := m.indexOf(key); i >= 0 {
+		return m.entries[i].Value, true
+	}
+
+	return nil, false
+}
+
+// Has returns true if the key exists in the map.
+func (m *SmallOrderedMap) Has(key interface{}) bool {
+	m.RLock()
+	defer m.RUnlock()
+	return m.indexOf(key) >= 0
+}
+
+// GetOrDefault returns the value for a key. If the key does not exist, returns
+// the default value instead.
+func (m *SmallOrderedMap) GetOrDefault(
+	key, defaultValue interface{}) interface{} {
+	if value, ok := m.Get(key); ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Set will set (or replace) a value for a key. If the key was new, then true
+// will be returned. The returned value will be false if the value was replaced
+// (even if the value was the same).
+func (m *SmallOrderedMap) Set(key, value interface{}) bool {
+	m.Lock()
+	defer m.Unlock()
+	if i := m.indexOf(key); i >= 0 {
+		m.entries[i].Value = value
+		return false
+	}
+
+	m.entries = append(m.entries, Entry{Key: key, Value: value})
+
+	return true
+}
+
+// Len returns the number of elements in the map.
+func (m *SmallOrderedMap) Len() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.entries)
+}
+
+// Keys returns all of the keys in the order they were inserted.
+func (m *SmallOrderedMap) Keys() (keys []interface{}) {
+	m.RLock()
+	defer m.RUnlock()
+	keys = make([]interface{}, len(m.entries))
+	for i := range m.entries {
+		keys[i] = m.entries[i].Key
+	}
+
+	return keys
+}
+
+// Delete will remove a key from the map. It will return true if the key was
+// removed (the key did exist).
+func (m *SmallOrderedMap) Delete(key interface{}) (didDelete bool) {
+	m.Lock()
+	defer m.Unlock()
+	i := m.indexOf(key)
+	if i < 0 {
+		return false
+	}
+
+	copy(m.entries[i:], m.entries[i+1:])
+	m.entries[len(m.entries)-1] = Entry{}
+	m.entries = m.entries[:len(m.entries)-1]
+
+	return true
+}
+
+// ForEach calls fn for each key and value in order until fn returns false. The
+// read lock is held while fn runs, so fn must not modify the map.
+func (m *SmallOrderedMap) ForEach(fn func(key, value interface{}) bool) {
+	m.RLock()
+	defer m.RUnlock()
+	for i := range m.entries {
+		if !fn(m.entries[i].Key, m.entries[i].Value) {
+			return
+		}
+	}
+}
+
+// Front will return the element that is the first (oldest Set element). If
+// there are no elements this will return nil.
+//
+// The element remembers its position rather than its key, so after a Delete
+// its Next and Prev may skip or repeat an entry.
+func (m *SmallOrderedMap) Front() *Element {
+	return m.elementAt(0)
+}
+
+// Back will return the element that is the last (most recent Set element). If
+// there are no elements this will return nil.
+func (m *SmallOrderedMap) Back() *Element {
+	m.RLock()
+	defer m.RUnlock()
+	return m.element(len(m.entries) - 1)
+}
+
+// elementAt returns the element at index i, or nil if there is none.
+func (m *SmallOrderedMap) elementAt(i int) *Element {
+	m.RLock()
+	defer m.RUnlock()
+	return m.element(i)
+}
+
+// element is elementAt without locking. The caller must hold the lock.
+func (m *SmallOrderedMap) element(i int) *Element {
+	if i < 0 || i >= len(m.entries) {
+		return nil
+	}
+
+	return &Element{
+		Key:   m.entries[i].Key,
+		Value: m.entries[i].Value,
+		small: m,
+		index: i,
+	}
+}
+
+// MarshalJSON encodes the map as JSON in the same form as
+// OrderedMap.MarshalJSON.
+func (m *SmallOrderedMap) MarshalJSON() ([]byte, error) {
+	m.RLock()
+	keys := make([]interface{}, len(m.entries))
+	values := make([]interface{}, len(m.entries))
+	for i := range m.entries {
+		keys[i], values[i] = m.entries[i].Key, m.entries[i].Value
+	}
+	m.RUnlock()
+
+	var buf = new(bytes.Buffer)
+	w := bufio.NewWriter(buf)
+	err := encodeKeysValues(w, keys, values, encodeValue)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON sets the keys and values from data in the same way as
+// OrderedMap.UnmarshalJSON. Nested JSON objects are decoded into an
+// *OrderedMap. Nothing is added to the map if data cannot be decoded.
+func (m *SmallOrderedMap) UnmarshalJSON(data []byte) error {
+	decoded := NewOrderedMap()
+	err := decoded.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range decoded.SnapshotEntries() {
+		m.Set(entry.Key, entry.Value)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmallOrderedMap(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		assert.Equal(t, 0, m.Len())
+		assert.Equal(t, []interface{}{}, m.Keys())
+		value, ok := m.Get("foo")
+		assert.Nil(t, value)
+		assert.False(t, ok)
+		assert.False(t, m.Has("foo"))
+		assert.Equal(t, "bar", m.GetOrDefault("foo", "bar"))
+		assert.False(t, m.Delete("foo"))
+	})
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		assert.True(t, m.Set("foo", 1))
+		assert.True(t, m.Set(2, "bar"))
+		assert.False(t, m.Set("foo", 3))
+		value, ok := m.Get("foo")
+		assert.Equal(t, 3, value)
+		assert.True(t, ok)
+		assert.True(t, m.Has(2))
+		assert.Equal(t, []interface{}{"foo", 2}, m.Keys())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		assert.True(t, m.Delete(2))
+		assert.Equal(t, []interface{}{1, 3}, m.Keys())
+		assert.Equal(t, 2, m.Len())
+		m.Set(2, "b")
+		assert.Equal(t, []interface{}{1, 3, 2}, m.Keys())
+	})
+
+	t.Run("ForEach", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		var keys []interface{}
+		m.ForEach(func(key, value interface{}) bool {
+			keys = append(keys, key)
+			return key != 2
+		})
+		assert.Equal(t, []interface{}{1, 2}, keys)
+	})
+
+	t.Run("ReadOnlyMap", func(t *testing.T) {
+		var m orderedmap.ReadOnlyMap = orderedmap.NewSmallOrderedMap()
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("FrontAndBack", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		assert.Nil(t, m.Front())
+		assert.Nil(t, m.Back())
+
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "c")
+		var keys []interface{}
+		for e := m.Front(); e != nil; e = e.Next() {
+			keys = append(keys, e.Key)
+		}
+		assert.Equal(t, []interface{}{1, 2, 3}, keys)
+
+		keys = nil
+		for e := m.Back(); e != nil; e = e.Prev() {
+			keys = append(keys, e.Value)
+		}
+		assert.Equal(t, []interface{}{"c", "b", "a"}, keys)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		m.Set("b", 1)
+		m.Set("a", []int{2})
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"b":1,"a":[2]}`, string(b))
+
+		m2 := orderedmap.NewSmallOrderedMap()
+		assert.NoError(t, json.Unmarshal(b, m2))
+		assert.Equal(t, []interface{}{"b", "a"}, m2.Keys())
+		assert.Equal(t, 1.0, m2.GetOrDefault("b", nil))
+		assert.Equal(t, []interface{}{2.0}, m2.GetOrDefault("a", nil))
+	})
+
+	t.Run("JSONPairs", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		m.Set(2, "b")
+		m.Set(1, "a")
+		b, err := m.MarshalJSON()
+		assert.NoError(t, err)
+		assert.Equal(t, `[[2,"b"],[1,"a"]]`, string(b))
+	})
+
+	t.Run("UnmarshalJSONError", func(t *testing.T) {
+		m := orderedmap.NewSmallOrderedMap()
+		err := m.UnmarshalJSON([]byte(`{"a":1,}`))
+		var decodeErr *orderedmap.DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.Equal(t, 0, m.Len())
+	})
+}
+
+func BenchmarkSmallOrderedMap(b *testing.B) {
+	for _, n := range []int{4, 8, 16, 32} {
+		b.Run(fmt.Sprintf("Small/Set%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := orderedmap.NewSmallOrderedMap()
+				for j := 0; j < n; j++ {
+					m.Set(j, true)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("OrderedMap/Set%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m := orderedmap.NewOrderedMap()
+				for j := 0; j < n; j++ {
+					m.Set(j, true)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Small/Get%d", n), func(b *testing.B) {
+			m := orderedmap.NewSmallOrderedMap()
+			for j := 0; j < n; j++ {
+				m.Set(j, true)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Get(i % n)
+			}
+		})
+
+		b.Run(fmt.Sprintf("OrderedMap/Get%d", n), func(b *testing.B) {
+			m := orderedmap.NewOrderedMap()
+			for j := 0; j < n; j++ {
+				m.Set(j, true)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Get(i % n)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package orderedmap
+
+import "container/list"
+
+// NewSortedMap creates a map that keeps its keys sorted by less rather than
+// in insertion order. Set and the other methods that add keys put each new
+// key in its sorted position, so Keys, Front, Back, iteration and JSON
+// encoding all see the keys in sorted order. Keys that are equal according to
+// less are kept in insertion order. Replacing a value does not move its key.
+//
+// Finding the position walks the list from the back, so adding keys in
+// ascending order is O(1) but adding a key in random order is O(n). A skip
+// list would make this O(log n) and may be worth it for large maps.
+//
+// SetBefore, SetAfter and MoveToIndex return ErrSorted since they would break
+// the order. SetKeepingPosition and MergeFront add keys in sorted position and
+// WithGetPromotesRecency has no effect.
+func NewSortedMap(less func(a, b interface{}) bool,
+	options ...Option) *OrderedMap {
+	m := NewOrderedMap(options...)
+	m.less = less
+
+	return m
+}
+
+// push adds a new element at the back of the list or, for a sorted map, in
+// its sorted position. With WithoutOrdering the element is returned without
+// adding it to the list. The caller must hold the write lock.
+func (m *OrderedMap) push(element *orderedMapElement) *list.Element {
+	if m.unordered {
+		return &list.Element{Value: element}
+	}
+
+	if m.less == nil {
+		return m.ll.PushBack(element)
+	}
+
+	for e := m.ll.Back(); e != nil; e = e.Prev() {
+		if !m.less(element.key, e.Value.(*orderedMapElement).key) {
+			return m.ll.InsertAfter(element, e)
+		}
+	}
+
+	return m.ll.PushFront(element)
+}
+
+// SearchInsertIndex returns the index at which key would be inserted to keep
+// the keys sorted by less, which is the index of the first key that sorts
+// after it. Keys equal to key according to less come before that index. The
+// keys must already be sorted by less, as they are in a map from NewSortedMap
+// with the same less, otherwise the result is only the first position where
+// key sorts before the existing key.
+//
+// It walks the list from the front, so it is O(n). It returns -1 for a map
+// created with WithoutOrdering, which has no positions.
+func (m *OrderedMap) SearchInsertIndex(key interface{},
+	less func(a, b interface{}) bool) int {
+	m.RLock()
+	defer m.RUnlock()
+	if m.unordered {
+		return -1
+	}
+
+	index := 0
+	for e := m.ll.Front(); e != nil; e = e.Next() {
+		if less(key, e.Value.(*orderedMapElement).key) {
+			break
+		}
+
+		index++
+	}
+
+	return index
+}
@@ -0,0 +1,110 @@
+package orderedmap_test
+
+import (
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func intLess(a, b interface{}) bool {
+	return a.(int) < b.(int)
+}
+
+func TestNewSortedMap(t *testing.T) {
+	t.Run("Set", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(intLess)
+		for _, key := range []int{5, 1, 4, 2, 3} {
+			m.Set(key, key*10)
+		}
+		assert.Equal(t, []interface{}{1, 2, 3, 4, 5}, m.Keys())
+		assert.Equal(t, 1, m.Front().Key)
+		assert.Equal(t, 5, m.Back().Key)
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("ReplaceDoesntMove", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(intLess)
+		m.Set(2, "a")
+		m.Set(1, "b")
+		m.Set(2, "c")
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+		assert.Equal(t, "c", m.GetOrDefault(2, nil))
+	})
+
+	t.Run("EqualKeysKeepInsertionOrder", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(func(a, b interface{}) bool {
+			return len(a.(string)) < len(b.(string))
+		})
+		m.Set("bb", true)
+		m.Set("a", true)
+		m.Set("cc", true)
+		m.Set("d", true)
+		assert.Equal(t, []interface{}{"a", "d", "bb", "cc"}, m.Keys())
+	})
+
+	t.Run("OtherInserts", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(intLess,
+			orderedmap.WithPositionHistory(1))
+		m.Set(1, true)
+		m.Set(3, true)
+		m.SetWithTTL(2, true, 0)
+		m.Delete(1)
+		m.SetKeepingPosition(4, true)
+		m.SetKeepingPosition(1, true)
+		other := orderedmap.NewOrderedMap()
+		other.Set(6, true)
+		other.Set(0, true)
+		m.MergeFront(other)
+		assert.Equal(t, []interface{}{0, 1, 2, 3, 4, 6}, m.Keys())
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("PositionedMethods", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(intLess)
+		m.Set(1, true)
+		m.Set(2, true)
+		assert.Equal(t, orderedmap.ErrSorted, m.SetBefore(1, 3, true))
+		assert.Equal(t, orderedmap.ErrSorted, m.SetAfter(1, 3, true))
+		assert.Equal(t, orderedmap.ErrSorted, m.MoveToIndex(2, 0))
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+	})
+
+	t.Run("GetDoesntPromote", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(intLess,
+			orderedmap.WithGetPromotesRecency(true))
+		m.Set(1, true)
+		m.Set(2, true)
+		m.Get(1)
+		assert.Equal(t, []interface{}{1, 2}, m.Keys())
+	})
+}
+
+func TestOrderedMap_SearchInsertIndex(t *testing.T) {
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.Equal(t, 0, m.SearchInsertIndex(1, intLess))
+	})
+
+	t.Run("Sorted", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(10, true)
+		m.Set(20, true)
+		m.Set(30, true)
+		assert.Equal(t, 0, m.SearchInsertIndex(5, intLess))
+		assert.Equal(t, 1, m.SearchInsertIndex(15, intLess))
+		assert.Equal(t, 2, m.SearchInsertIndex(20, intLess))
+		assert.Equal(t, 3, m.SearchInsertIndex(35, intLess))
+	})
+
+	t.Run("MatchesSortedMap", func(t *testing.T) {
+		m := orderedmap.NewSortedMap(intLess)
+		for _, key := range []int{8, 2, 6, 4} {
+			m.Set(key, true)
+		}
+		index := m.SearchInsertIndex(5, intLess)
+		m.Set(5, true)
+		_, actual, _ := m.GetWithIndex(5)
+		assert.Equal(t, index, actual)
+	})
+}
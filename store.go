@@ -0,0 +1,105 @@
+package orderedmap
+
+import "container/list"
+
+// Store is durable storage, such as a file or a database, that an OrderedMap
+// writes its entries through to. See NewOrderedMapFromStore.
+//
+// A Store provides write-through persistence, not a replacement for the map's
+// own storage: the map still holds every entry in memory and serves all reads
+// itself, so a Store has no Get and cannot hold more entries than fit in
+// memory. It only needs to record changes and give them back when the map is
+// loaded. Set and Delete are called with the map's write lock held, so they
+// must not call methods on the map. A Store that can fail while writing must
+// keep track of the error itself.
+type Store interface {
+	// Set records the value of key. A new key goes after all of the others.
+	Set(key, value interface{})
+
+	// Delete removes key. It is not an error if key does not exist.
+	Delete(key interface{})
+
+	// Iterate calls fn for each entry, in the order they were first Set,
+	// until fn returns false.
+	Iterate(fn func(key, value interface{}) bool) error
+}
+
+// NewOrderedMapFromStore creates a map holding the entries in s and then keeps
+// s up to date: every key that is added, every value that is replaced and
+// every key that is removed (including by eviction, expiry and Clear) is
+// passed on to s.
+//
+// Moving a key is not passed on, so when the map is loaded again its keys are
+// in the order they were first added.
+//
+// The options are applied before the entries are loaded. Entries that do not
+// survive the load, such as those evicted by WithCapacity, are deleted from s
+// so that the two agree. It returns the error
+// from Iterate, or from checking the keys and values (see WithStringKeysOnly
+// and WithValueValidator).
+func NewOrderedMapFromStore(s Store, options ...Option) (*OrderedMap, error) {
+	var keys, values []interface{}
+	err := s.Iterate(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		values = append(values, value)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewOrderedMapSize(len(keys), options...)
+	err = m.checkEntries(keys, values)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	for i, key := range keys {
+		m.set(key, values[i])
+	}
+
+	for _, key := range keys {
+		if _, ok := m.kv[key]; !ok {
+			s.Delete(key)
+		}
+	}
+
+	m.store = s
+	m.Unlock()
+
+	return m, nil
+}
+
+// clearStore deletes every key from the store before the map is cleared. The
+// caller must hold the write lock.
+func (m *OrderedMap) clearStore() {
+	m.elements(func(e *list.Element) bool {
+		m.store.Delete(e.Value.(*orderedMapElement).key)
+		return true
+	})
+}
+
+// MemoryStore is a Store that keeps its entries in memory. It is mostly useful
+// for testing and as an example of a Store. The zero value is an empty store
+// ready to use.
+type MemoryStore struct {
+	m OrderedMap
+}
+
+// Set records the value of key.
+func (s *MemoryStore) Set(key, value interface{}) {
+	s.m.Set(key, value)
+}
+
+// Delete removes key.
+func (s *MemoryStore) Delete(key interface{}) {
+	s.m.Delete(key)
+}
+
+// Iterate calls fn for each entry in order until fn returns false. It never
+// returns an error.
+func (s *MemoryStore) Iterate(fn func(key, value interface{}) bool) error {
+	s.m.ForEach(fn)
+	return nil
+}
@@ -0,0 +1,108 @@
+package orderedmap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct {
+	orderedmap.MemoryStore
+}
+
+func (s *failingStore) Iterate(fn func(key, value interface{}) bool) error {
+	return errors.New("failed")
+}
+
+func storeEntries(s orderedmap.Store) (entries []orderedmap.Entry) {
+	s.Iterate(func(key, value interface{}) bool {
+		entries = append(entries, orderedmap.Entry{Key: key, Value: value})
+		return true
+	})
+
+	return entries
+}
+
+func TestNewOrderedMapFromStore(t *testing.T) {
+	t.Run("Load", func(t *testing.T) {
+		s := &orderedmap.MemoryStore{}
+		s.Set("foo", 1)
+		s.Set("bar", 2)
+		m, err := orderedmap.NewOrderedMapFromStore(s)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"foo", "bar"}, m.Keys())
+		assert.Equal(t, 2, m.GetOrDefault("bar", nil))
+	})
+
+	t.Run("WriteThrough", func(t *testing.T) {
+		s := &orderedmap.MemoryStore{}
+		m, err := orderedmap.NewOrderedMapFromStore(s)
+		assert.NoError(t, err)
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		m.Set("baz", 3)
+		m.Set("foo", 4)
+		m.Delete("bar")
+		assert.Equal(t, []orderedmap.Entry{{"foo", 4}, {"baz", 3}},
+			storeEntries(s))
+
+		m.Clear()
+		assert.Nil(t, storeEntries(s))
+	})
+
+	t.Run("Eviction", func(t *testing.T) {
+		s := &orderedmap.MemoryStore{}
+		m, err := orderedmap.NewOrderedMapFromStore(s,
+			orderedmap.WithCapacity(2))
+		assert.NoError(t, err)
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+		m.Set("baz", 3)
+		assert.Equal(t, []orderedmap.Entry{{"bar", 2}, {"baz", 3}},
+			storeEntries(s))
+	})
+
+	t.Run("EvictionOnLoad", func(t *testing.T) {
+		s := &orderedmap.MemoryStore{}
+		s.Set("a", 1)
+		s.Set("b", 2)
+		s.Set("c", 3)
+		m, err := orderedmap.NewOrderedMapFromStore(s,
+			orderedmap.WithCapacity(2))
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"b", "c"}, m.Keys())
+		assert.Equal(t, []orderedmap.Entry{{"b", 2}, {"c", 3}},
+			storeEntries(s))
+
+		m.Set("d", 4)
+		assert.Equal(t, []orderedmap.Entry{{"c", 3}, {"d", 4}},
+			storeEntries(s))
+	})
+
+	t.Run("CopyOnWrite", func(t *testing.T) {
+		s := &orderedmap.MemoryStore{}
+		s.Set("foo", 1)
+		s.Set("bar", 2)
+		m, err := orderedmap.NewOrderedMapFromStore(s,
+			orderedmap.WithCopyOnWrite())
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"foo", "bar"}, m.Keys())
+		assert.Equal(t, 2, m.Len())
+	})
+
+	t.Run("IterateError", func(t *testing.T) {
+		m, err := orderedmap.NewOrderedMapFromStore(&failingStore{})
+		assert.EqualError(t, err, "failed")
+		assert.Nil(t, m)
+	})
+
+	t.Run("NonStringKey", func(t *testing.T) {
+		s := &orderedmap.MemoryStore{}
+		s.Set(1, "foo")
+		_, err := orderedmap.NewOrderedMapFromStore(s,
+			orderedmap.WithStringKeysOnly())
+		assert.Equal(t, orderedmap.ErrNonStringKey, err)
+	})
+}
@@ -0,0 +1,124 @@
+package orderedmap
+
+import (
+	"container/list"
+	"math"
+	"time"
+)
+
+// SetWithTTL sets a value for a key like Set, except that the key will expire
+// once ttl has passed. A ttl that is not positive expires the key immediately.
+//
+// Expired keys are treated as missing by Get, GetOrDefault and GetWithIndex.
+// However, they keep their position and are still counted by Len and returned
+// by Keys and iteration until they are removed with DeleteExpired.
+func (m *OrderedMap) SetWithTTL(
+	key, value interface{}, ttl time.Duration) bool {
+	value = m.mustValidValue(key, value)
+	m.Lock()
+	defer m.Unlock()
+	expires := expiresAt(ttl)
+	if element, ok := m.kv[key]; ok {
+		if !m.appendOnly {
+			e := element.Value.(*orderedMapElement)
+			m.replace(e, value)
+			e.expires = expires
+		}
+
+		return false
+	}
+
+	m.mustCheckKey(key)
+	m.lazyInit()
+	key = m.intern(key)
+	element := &orderedMapElement{key: key, value: value, expires: expires}
+	m.kv[key] = m.push(element)
+	m.inserted(element)
+
+	return true
+}
+
+// expiresAt returns the time in Unix nanoseconds that a key set now with ttl
+// expires. A ttl too long to represent never expires in practice, so it is
+// capped rather than allowed to overflow into the past.
+func expiresAt(ttl time.Duration) int64 {
+	now := time.Now().UnixNano()
+	if int64(ttl) > math.MaxInt64-now {
+		return math.MaxInt64
+	}
+
+	return now + int64(ttl)
+}
+
+// TTL returns the time remaining before a key expires. ok will be false if the
+// key does not exist, has already expired or was not set with a TTL.
+func (m *OrderedMap) TTL(key interface{}) (remaining time.Duration, ok bool) {
+	m.RLock()
+	defer m.RUnlock()
+	element, ok := m.kv[key]
+	if !ok {
+		return 0, false
+	}
+
+	e := element.Value.(*orderedMapElement)
+	if e.expires == 0 || e.expired() {
+		return 0, false
+	}
+
+	return time.Duration(e.expires - time.Now().UnixNano()), true
+}
+
+// Touch resets the expiry of a key so that it expires ttl from now. It returns
+// false without changing anything if the key does not exist, has already
+// expired or was not set with a TTL.
+func (m *OrderedMap) Touch(key interface{}, ttl time.Duration) bool {
+	m.Lock()
+	defer m.Unlock()
+	element, ok := m.kv[key]
+	if !ok {
+		return false
+	}
+
+	e := element.Value.(*orderedMapElement)
+	if e.expires == 0 || e.expired() {
+		return false
+	}
+
+	e.expires = expiresAt(ttl)
+
+	return true
+}
+
+// LenLive returns the number of keys that have not expired. Unlike Len, which
+// is constant time and includes expired keys that have not been removed yet,
+// LenLive checks every entry so it is O(n). Expired keys are not removed.
+func (m *OrderedMap) LenLive() (count int) {
+	m.RLock()
+	defer m.RUnlock()
+	m.elements(func(element *list.Element) bool {
+		if !element.Value.(*orderedMapElement).expired() {
+			count++
+		}
+
+		return true
+	})
+
+	return count
+}
+
+// DeleteExpired removes all of the keys that have expired and returns the
+// number of keys removed. The order of the remaining keys is unchanged.
+func (m *OrderedMap) DeleteExpired() (count int) {
+	m.Lock()
+	defer m.Unlock()
+	m.elements(func(element *list.Element) bool {
+		if element.Value.(*orderedMapElement).expired() {
+			m.remove(element)
+			count++
+		}
+
+		return true
+	})
+
+	return count
+}
@@ -0,0 +1,133 @@
+package orderedmap_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_SetWithTTL(t *testing.T) {
+	t.Run("ReturnsTrueIfKeyIsNew", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.True(t, m.SetWithTTL("foo", "bar", time.Hour))
+		assert.False(t, m.SetWithTTL("foo", "baz", time.Hour))
+	})
+
+	t.Run("LiveKeyCanBeRead", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", time.Hour)
+		value, ok := m.Get("foo")
+		assert.True(t, ok)
+		assert.Equal(t, "bar", value)
+	})
+
+	t.Run("ExpiredKeyIsMissing", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", -time.Second)
+		_, ok := m.Get("foo")
+		assert.False(t, ok)
+		assert.Equal(t, "baz", m.GetOrDefault("foo", "baz"))
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("VeryLongTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", time.Duration(math.MaxInt64))
+		m.SetWithTTL("baz", "qux", 250*365*24*time.Hour)
+		assert.Equal(t, "bar", m.GetOrDefault("foo", nil))
+		assert.Equal(t, "qux", m.GetOrDefault("baz", nil))
+		remaining, ok := m.TTL("foo")
+		assert.True(t, ok)
+		assert.True(t, remaining > 0)
+	})
+
+	t.Run("SetRemovesTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", time.Hour)
+		m.Set("foo", "baz")
+		_, ok := m.TTL("foo")
+		assert.False(t, ok)
+	})
+}
+
+func TestOrderedMap_TTL(t *testing.T) {
+	t.Run("MissingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		_, ok := m.TTL("foo")
+		assert.False(t, ok)
+	})
+
+	t.Run("KeyWithoutTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "bar")
+		_, ok := m.TTL("foo")
+		assert.False(t, ok)
+	})
+
+	t.Run("ReturnsRemaining", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", time.Hour)
+		remaining, ok := m.TTL("foo")
+		assert.True(t, ok)
+		assert.True(t, remaining > 59*time.Minute && remaining <= time.Hour)
+	})
+}
+
+func TestOrderedMap_Touch(t *testing.T) {
+	t.Run("MissingKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		assert.False(t, m.Touch("foo", time.Hour))
+	})
+
+	t.Run("KeyWithoutTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", "bar")
+		assert.False(t, m.Touch("foo", time.Hour))
+	})
+
+	t.Run("ExpiredKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", -time.Second)
+		assert.False(t, m.Touch("foo", time.Hour))
+	})
+
+	t.Run("ExtendsExpiry", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", time.Minute)
+		assert.True(t, m.Touch("foo", time.Hour))
+		remaining, _ := m.TTL("foo")
+		assert.True(t, remaining > time.Minute)
+	})
+
+	t.Run("VeryLongTTL", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.SetWithTTL("foo", "bar", time.Minute)
+		assert.True(t, m.Touch("foo", time.Duration(math.MaxInt64)))
+		assert.Equal(t, "bar", m.GetOrDefault("foo", nil))
+	})
+}
+
+func TestOrderedMap_LenLive(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	assert.Equal(t, 0, m.LenLive())
+
+	m.SetWithTTL(1, true, -time.Second)
+	m.Set(2, true)
+	m.SetWithTTL(3, true, time.Hour)
+	assert.Equal(t, 2, m.LenLive())
+	assert.Equal(t, 3, m.Len())
+}
+
+func TestOrderedMap_DeleteExpired(t *testing.T) {
+	m := orderedmap.NewOrderedMap()
+	m.SetWithTTL(1, true, -time.Second)
+	m.Set(2, true)
+	m.SetWithTTL(3, true, time.Hour)
+	m.SetWithTTL(4, true, -time.Second)
+	assert.Equal(t, 2, m.DeleteExpired())
+	assert.Equal(t, []interface{}{2, 3}, m.Keys())
+	assert.NoError(t, m.Validate())
+}
@@ -0,0 +1,116 @@
+package orderedmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// typedEnvelope is a value encoded with WithTypedJSON.
+type typedEnvelope struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+// encodeTypedValue writes value wrapped in a typed envelope. Nested maps are
+// also encoded with typed envelopes.
+func encodeTypedValue(w *bufio.Writer, value interface{}) error {
+	var t string
+	switch v := value.(type) {
+	case int:
+		t = "int"
+	case int64:
+		t = "int64"
+	case float64:
+		t = "float64"
+	case string:
+		t = "string"
+	case bool:
+		t = "bool"
+	case *OrderedMap:
+		if v != nil {
+			t = "map"
+		}
+	}
+
+	w.WriteByte('{')
+	if t != "" {
+		w.WriteString(`"t":"` + t + `",`)
+	}
+
+	w.WriteString(`"v":`)
+	var err error
+	if t == "map" {
+		err = value.(*OrderedMap).encodeJSON(w, encodeTypedValue)
+	} else {
+		err = encodeValue(w, value)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	w.WriteByte('}')
+
+	return nil
+}
+
+// decodeMapValue decodes the next value of the map being decoded, unwrapping it
+// from a typed envelope with WithTypedJSON.
+func (m *OrderedMap) decodeMapValue(dec *json.Decoder) (interface{}, error) {
+	if !m.typedJSON {
+		return decodeValue(dec)
+	}
+
+	var envelope typedEnvelope
+	err := dec.Decode(&envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.Value == nil {
+		return nil, fmt.Errorf(`typed value has no "v"`)
+	}
+
+	var target interface{}
+	switch envelope.Type {
+	case "":
+		return decodeValue(json.NewDecoder(bytes.NewReader(envelope.Value)))
+
+	case "int":
+		target = new(int)
+
+	case "int64":
+		target = new(int64)
+
+	case "float64":
+		target = new(float64)
+
+	case "string":
+		target = new(string)
+
+	case "bool":
+		target = new(bool)
+
+	case "map":
+		nested := NewOrderedMap(WithTypedJSON())
+		err = nested.UnmarshalJSON(envelope.Value)
+		if decodeErr, ok := err.(*DecodeError); ok {
+			return nil, decodeErr.Err
+		}
+
+		return nested, err
+
+	default:
+		return nil, fmt.Errorf("unknown typed value type %q", envelope.Type)
+	}
+
+	err = json.Unmarshal(envelope.Value, target)
+	if err != nil {
+		return nil, err
+	}
+
+	return reflect.ValueOf(target).Elem().Interface(), nil
+}
@@ -0,0 +1,86 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTypedJSON(t *testing.T) {
+	t.Run("Marshal", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON())
+		m.Set("a", 5)
+		m.Set("b", "foo")
+		m.Set("c", nil)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a":{"t":"int","v":5},"b":{"t":"string","v":"foo"},`+
+			`"c":{"v":null}}`, string(b))
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		nested := orderedmap.NewOrderedMap()
+		nested.Set("y", int64(2))
+		nested.Set("x", 1)
+
+		m := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON())
+		m.Set("int", 1)
+		m.Set("int64", int64(1)<<60+1)
+		m.Set("float64", 1.0)
+		m.Set("string", "foo")
+		m.Set("bool", true)
+		m.Set("map", nested)
+		m.Set("slice", []int{1})
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+
+		m2 := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON())
+		assert.NoError(t, json.Unmarshal(b, m2))
+		assert.Equal(t, m.Keys(), m2.Keys())
+		assert.Equal(t, 1, m2.GetOrDefault("int", nil))
+		assert.Equal(t, int64(1)<<60+1, m2.GetOrDefault("int64", nil))
+		assert.Equal(t, 1.0, m2.GetOrDefault("float64", nil))
+		assert.Equal(t, "foo", m2.GetOrDefault("string", nil))
+		assert.Equal(t, true, m2.GetOrDefault("bool", nil))
+		assert.Equal(t, []interface{}{1.0}, m2.GetOrDefault("slice", nil))
+
+		nested2 := m2.GetOrDefault("map", nil).(*orderedmap.OrderedMap)
+		assert.Equal(t, []interface{}{"y", "x"}, nested2.Keys())
+		assert.Equal(t, int64(2), nested2.GetOrDefault("y", nil))
+		assert.Equal(t, 1, nested2.GetOrDefault("x", nil))
+	})
+
+	t.Run("NonStringKeys", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON())
+		m.Set(1, 2)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `[[1,{"t":"int","v":2}]]`, string(b))
+
+		m2 := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON(),
+			orderedmap.WithJSONKeyType(0))
+		assert.NoError(t, json.Unmarshal(b, m2))
+		assert.Equal(t, 2, m2.GetOrDefault(1, nil))
+	})
+
+	t.Run("UnknownType", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON())
+		err := json.Unmarshal([]byte(`{"a":{"t":"uint8","v":1}}`), m)
+		assert.Error(t, err)
+		assert.Equal(t, 0, m.Len())
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON())
+		err := json.Unmarshal([]byte(`{"a":{"t":"int","v":"1"}}`), m)
+		assert.Error(t, err)
+	})
+
+	t.Run("MissingValue", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithTypedJSON())
+		err := json.Unmarshal([]byte(`{"a":{"t":"int"}}`), m)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,41 @@
+package orderedmap
+
+import "container/list"
+
+// elements calls fn for each element in order, or in the order of the Go map
+// with WithoutOrdering, until fn returns false. fn may remove the element it
+// is given. The caller must hold the lock.
+func (m *OrderedMap) elements(fn func(element *list.Element) bool) {
+	if m.unordered {
+		for _, element := range m.kv {
+			if !fn(element) {
+				return
+			}
+		}
+
+		return
+	}
+
+	for element := m.ll.Front(); element != nil; {
+		next := element.Next()
+		if !fn(element) {
+			return
+		}
+
+		element = next
+	}
+}
+
+// checkOrdered returns an error if keys cannot be put at a particular
+// position, because the map is sorted or unordered.
+func (m *OrderedMap) checkOrdered() error {
+	if m.less != nil {
+		return ErrSorted
+	}
+
+	if m.unordered {
+		return ErrUnordered
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package orderedmap_test
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func sortedInts(keys []interface{}) []int {
+	ints := make([]int, len(keys))
+	for i, key := range keys {
+		ints[i] = key.(int)
+	}
+	sort.Ints(ints)
+
+	return ints
+}
+
+func TestWithoutOrdering(t *testing.T) {
+	t.Run("SetGetDelete", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		assert.True(t, m.Set(1, "a"))
+		assert.True(t, m.Set(2, "b"))
+		assert.True(t, m.Set(3, "c"))
+		assert.False(t, m.Set(1, "A"))
+		assert.Equal(t, "A", m.GetOrDefault(1, nil))
+		assert.True(t, m.Delete(2))
+		assert.False(t, m.Has(2))
+		assert.Equal(t, 2, m.Len())
+		assert.Equal(t, []int{1, 3}, sortedInts(m.Keys()))
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("Iteration", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		m.Set(1, 10)
+		m.Set(2, 20)
+		keys, values := m.KeysValues()
+		for i, key := range keys {
+			assert.Equal(t, key.(int)*10, values[i])
+		}
+
+		var visited []interface{}
+		m.ForEach(func(key, value interface{}) bool {
+			visited = append(visited, key)
+			return true
+		})
+		assert.Equal(t, []int{1, 2}, sortedInts(visited))
+		assert.Len(t, m.SnapshotEntries(), 2)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		m.Set("a", 1)
+		b, err := json.Marshal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a":1}`, string(b))
+	})
+
+	t.Run("Compact", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Compact()
+		assert.Equal(t, []int{1, 2}, sortedInts(m.Keys()))
+	})
+
+	t.Run("PositionedMethods", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		m.Set(1, "a")
+		m.Set(2, "b")
+		assert.Equal(t, orderedmap.ErrUnordered, m.SetBefore(1, 3, "c"))
+		assert.Equal(t, orderedmap.ErrUnordered, m.SetAfter(1, 3, "c"))
+		assert.Equal(t, orderedmap.ErrUnordered, m.MoveToIndex(1, 1))
+		assert.Equal(t, orderedmap.ErrUnordered,
+			m.ReorderByKeys([]interface{}{2, 1}))
+		assert.Nil(t, m.Front())
+		assert.Nil(t, m.Back())
+	})
+
+	t.Run("GetWithIndex", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		m.Set(1, "a")
+		value, index, ok := m.GetWithIndex(1)
+		assert.Equal(t, "a", value)
+		assert.Equal(t, -1, index)
+		assert.True(t, ok)
+	})
+
+	t.Run("DeleteWithPositionHistory", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering(),
+			orderedmap.WithPositionHistory(2))
+		m.Set(1, "a")
+		m.Set(2, "b")
+		assert.True(t, m.Delete(1))
+		m.SetKeepingPosition(1, "A")
+		assert.Equal(t, "A", m.GetOrDefault(1, nil))
+		assert.Equal(t, []int{1, 2}, sortedInts(m.Keys()))
+	})
+
+	t.Run("SplitAt", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		m.Set(1, "a")
+		m.Set(2, "b")
+		head, tail, ok := m.SplitAt(2)
+		assert.True(t, ok)
+		assert.Equal(t, 0, head.Len())
+		assert.Equal(t, []interface{}{2}, tail.Keys())
+		assert.Equal(t, "b", tail.GetOrDefault(2, nil))
+	})
+
+	t.Run("Fingerprint", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		empty := m.Fingerprint()
+		for i := 0; i < 20; i++ {
+			m.Set(i, i)
+		}
+		assert.NotEqual(t, empty, m.Fingerprint())
+
+		m2 := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		for i := 19; i >= 0; i-- {
+			m2.Set(i, i)
+		}
+		assert.Equal(t, m.Fingerprint(), m2.Fingerprint())
+
+		m2.Set(0, "changed")
+		assert.NotEqual(t, m.Fingerprint(), m2.Fingerprint())
+	})
+
+	t.Run("SearchInsertIndex", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithoutOrdering())
+		m.Set(1, "a")
+		m.Set(3, "c")
+		assert.Equal(t, -1, m.SearchInsertIndex(2,
+			func(a, b interface{}) bool {
+				return a.(int) < b.(int)
+			}))
+	})
+}
@@ -0,0 +1,108 @@
+package orderedmap
+
+import (
+	"container/list"
+	"reflect"
+)
+
+// valueIndex groups elements by value so that KeysForValue does not have to
+// compare every value in the map.
+type valueIndex struct {
+	eq      func(a, b interface{}) bool
+	buckets []*valueBucket
+}
+
+// valueBucket holds every element with a value equal to value.
+type valueBucket struct {
+	value    interface{}
+	elements map[*orderedMapElement]struct{}
+}
+
+// add puts element in the bucket for its value, creating the bucket if needed.
+func (idx *valueIndex) add(element *orderedMapElement) {
+	bucket := idx.find(element.value)
+	if bucket == nil {
+		bucket = &valueBucket{
+			value:    element.value,
+			elements: make(map[*orderedMapElement]struct{}),
+		}
+		idx.buckets = append(idx.buckets, bucket)
+	}
+
+	bucket.elements[element] = struct{}{}
+}
+
+// remove takes element out of its bucket, dropping the bucket once it is
+// empty.
+func (idx *valueIndex) remove(element *orderedMapElement) {
+	for i, bucket := range idx.buckets {
+		if _, ok := bucket.elements[element]; !ok {
+			continue
+		}
+
+		delete(bucket.elements, element)
+		if len(bucket.elements) == 0 {
+			idx.buckets = append(idx.buckets[:i], idx.buckets[i+1:]...)
+		}
+
+		return
+	}
+}
+
+// find returns the bucket for value, or nil if there is no such bucket.
+func (idx *valueIndex) find(value interface{}) *valueBucket {
+	for _, bucket := range idx.buckets {
+		if idx.eq(bucket.value, value) {
+			return bucket
+		}
+	}
+
+	return nil
+}
+
+// KeysForValue returns every key with a value equal to value, in insertion
+// order. It returns nil if there are none.
+//
+// If the map was created with WithValueIndex the index is used to find the
+// keys and values are compared with the function given to it. Otherwise every
+// value in the map is compared with reflect.DeepEqual, which is O(n).
+func (m *OrderedMap) KeysForValue(value interface{}) (keys []interface{}) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.values == nil {
+		m.elements(func(e *list.Element) bool {
+			element := e.Value.(*orderedMapElement)
+			if reflect.DeepEqual(element.value, value) {
+				keys = append(keys, element.key)
+			}
+
+			return true
+		})
+
+		return keys
+	}
+
+	bucket := m.values.find(value)
+	if bucket == nil {
+		return nil
+	}
+
+	if m.unordered {
+		for element := range bucket.elements {
+			keys = append(keys, element.key)
+		}
+
+		return keys
+	}
+
+	// The elements in a bucket are not ordered, so walk the list until they
+	// have all been found to put them in order.
+	for e := m.ll.Front(); len(keys) < len(bucket.elements); e = e.Next() {
+		element := e.Value.(*orderedMapElement)
+		if _, ok := bucket.elements[element]; ok {
+			keys = append(keys, element.key)
+		}
+	}
+
+	return keys
+}
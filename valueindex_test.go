@@ -0,0 +1,89 @@
+package orderedmap_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_KeysForValue(t *testing.T) {
+	t.Run("WithoutIndex", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set(1, "a")
+		m.Set(2, []int{1})
+		m.Set(3, "a")
+		assert.Equal(t, []interface{}{1, 3}, m.KeysForValue("a"))
+		assert.Equal(t, []interface{}{2}, m.KeysForValue([]int{1}))
+		assert.Nil(t, m.KeysForValue("b"))
+	})
+
+	t.Run("EmptyMap", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithValueIndex(reflect.DeepEqual))
+		assert.Nil(t, m.KeysForValue("a"))
+	})
+
+	t.Run("InsertionOrder", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithValueIndex(reflect.DeepEqual))
+		m.Set(1, "a")
+		m.Set(2, "b")
+		m.Set(3, "a")
+		assert.NoError(t, m.SetBefore(1, 4, "a"))
+		assert.Equal(t, []interface{}{4, 1, 3}, m.KeysForValue("a"))
+		assert.Equal(t, []interface{}{2}, m.KeysForValue("b"))
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithValueIndex(reflect.DeepEqual))
+		m.Set(1, "a")
+		m.Set(2, "a")
+		m.Set(1, "b")
+		assert.Equal(t, []interface{}{2}, m.KeysForValue("a"))
+		assert.Equal(t, []interface{}{1}, m.KeysForValue("b"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithValueIndex(reflect.DeepEqual))
+		m.Set(1, "a")
+		m.Set(2, "a")
+		m.Delete(1)
+		assert.Equal(t, []interface{}{2}, m.KeysForValue("a"))
+		m.Delete(2)
+		assert.Nil(t, m.KeysForValue("a"))
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithValueIndex(reflect.DeepEqual))
+		m.Set(1, "a")
+		m.Clear()
+		assert.Nil(t, m.KeysForValue("a"))
+		m.Set(2, "a")
+		assert.Equal(t, []interface{}{2}, m.KeysForValue("a"))
+	})
+
+	t.Run("Evicted", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithCapacity(1),
+			orderedmap.WithValueIndex(reflect.DeepEqual))
+		m.Set(1, "a")
+		m.Set(2, "a")
+		assert.Equal(t, []interface{}{2}, m.KeysForValue("a"))
+	})
+
+	t.Run("CustomEquality", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(
+			orderedmap.WithValueIndex(func(a, b interface{}) bool {
+				return strings.EqualFold(a.(string), b.(string))
+			}))
+		m.Set(1, "foo")
+		m.Set(2, "FOO")
+		m.Set(3, "bar")
+		assert.Equal(t, []interface{}{1, 2}, m.KeysForValue("Foo"))
+	})
+}
@@ -0,0 +1,45 @@
+package orderedmap
+
+import "sync"
+
+// ReadView provides reads of an OrderedMap while its read lock is held, so
+// that several reads are consistent with each other. It is created with
+// RLockView and must not be used after it has been released.
+type ReadView struct {
+	m *OrderedMap
+}
+
+// RLockView read locks the map and returns a view for reading it, along with a
+// function that releases the lock. The map cannot be written to until release
+// is called, so forgetting to call it will block all writers forever. Calling
+// release more than once has no effect.
+//
+//	view, release := m.RLockView()
+//	defer release()
+//	for _, key := range view.Keys() {
+//		value, _ := view.Get(key)
+//		fmt.Println(key, value)
+//	}
+func (m *OrderedMap) RLockView() (view *ReadView, release func()) {
+	m.RLock()
+	var once sync.Once
+
+	return &ReadView{m}, func() {
+		once.Do(m.RUnlock)
+	}
+}
+
+// Get is the same as OrderedMap.Get.
+func (v *ReadView) Get(key interface{}) (interface{}, bool) {
+	return v.m.get(key)
+}
+
+// Keys is the same as OrderedMap.Keys.
+func (v *ReadView) Keys() []interface{} {
+	return v.m.keys()
+}
+
+// Len is the same as OrderedMap.Len.
+func (v *ReadView) Len() int {
+	return len(v.m.kv)
+}
@@ -0,0 +1,53 @@
+package orderedmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_RLockView(t *testing.T) {
+	t.Run("Reads", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		m.Set("foo", 1)
+		m.Set("bar", 2)
+
+		view, release := m.RLockView()
+		defer release()
+		assert.Equal(t, []interface{}{"foo", "bar"}, view.Keys())
+		assert.Equal(t, 2, view.Len())
+		value, ok := view.Get("bar")
+		assert.True(t, ok)
+		assert.Equal(t, 2, value)
+		_, ok = view.Get("baz")
+		assert.False(t, ok)
+	})
+
+	t.Run("BlocksWritersUntilReleased", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		view, release := m.RLockView()
+
+		done := make(chan struct{})
+		go func() {
+			m.Set("foo", 1)
+			close(done)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, 0, view.Len())
+		release()
+		<-done
+		assert.Equal(t, 1, m.Len())
+	})
+
+	t.Run("ReleaseTwice", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		_, release := m.RLockView()
+		release()
+		release()
+		m.Set("foo", 1)
+		assert.Equal(t, 1, m.Len())
+	})
+}
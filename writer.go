@@ -0,0 +1,131 @@
+package orderedmap
+
+// Writer batches Set and Delete calls to an OrderedMap. It is created with
+// BufferedWriter.
+//
+// Operations are queued and applied by a background goroutine, which takes the
+// write lock once for each batch of queued operations rather than once per
+// call. This reduces lock contention when many goroutines write to the same
+// map. Operations are applied in the order they were queued.
+//
+// Reads on the map may not see writes that are still queued; call Flush to
+// wait for them. A Writer is safe for concurrent use, but Set, Delete and Flush
+// must not be called during or after Close.
+type Writer struct {
+	m    *OrderedMap
+	ops  chan writerOp
+	done chan struct{}
+}
+
+type writerOp struct {
+	key, value interface{}
+	delete     bool
+
+	// flushed is closed once every operation before it has been applied.
+	flushed chan struct{}
+}
+
+// BufferedWriter returns a Writer for m that can queue up to bufSize
+// operations before Set and Delete block. Close must be called to stop the
+// background goroutine.
+//
+// BufferedWriter panics if bufSize is negative.
+func (m *OrderedMap) BufferedWriter(bufSize int) *Writer {
+	if bufSize < 0 {
+		panic("orderedmap: BufferedWriter size must not be negative")
+	}
+
+	w := &Writer{
+		m:    m,
+		ops:  make(chan writerOp, bufSize),
+		done: make(chan struct{}),
+	}
+	go w.run()
+
+	return w
+}
+
+// hashable is never written to. Looking a key up in it panics if the key cannot
+// be hashed, even though the map is nil.
+var hashable map[interface{}]struct{}
+
+// mustHash panics if key cannot be used as a map key, as it would in
+// OrderedMap.Set. Keys are checked before they are queued so that the panic
+// happens in the caller rather than in the background goroutine, where it
+// could not be recovered.
+func mustHash(key interface{}) {
+	_ = hashable[key]
+}
+
+// Set queues setting key to value, as OrderedMap.Set does.
+func (w *Writer) Set(key, value interface{}) {
+	mustHash(key)
+	w.m.mustCheckKey(key)
+	value = w.m.mustValidValue(key, value)
+	w.ops <- writerOp{key: key, value: value}
+}
+
+// Delete queues removing key, as OrderedMap.Delete does.
+func (w *Writer) Delete(key interface{}) {
+	mustHash(key)
+	w.ops <- writerOp{key: key, delete: true}
+}
+
+// Flush blocks until every operation queued before it has been applied.
+func (w *Writer) Flush() {
+	flushed := make(chan struct{})
+	w.ops <- writerOp{flushed: flushed}
+	<-flushed
+}
+
+// Close applies any queued operations and stops the background goroutine.
+func (w *Writer) Close() {
+	close(w.ops)
+	<-w.done
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	for op := range w.ops {
+		w.m.Lock()
+		var flushed []chan struct{}
+		flushed = w.apply(op, flushed)
+
+		// Apply whatever else is already queued under the same lock.
+	drain:
+		for {
+			select {
+			case op, ok := <-w.ops:
+				if !ok {
+					break drain
+				}
+
+				flushed = w.apply(op, flushed)
+
+			default:
+				break drain
+			}
+		}
+
+		w.m.Unlock()
+		for _, ch := range flushed {
+			close(ch)
+		}
+	}
+}
+
+func (w *Writer) apply(op writerOp,
+	flushed []chan struct{}) []chan struct{} {
+	switch {
+	case op.flushed != nil:
+		flushed = append(flushed, op.flushed)
+
+	case op.delete:
+		w.m.deleteKey(op.key)
+
+	default:
+		w.m.set(op.key, op.value)
+	}
+
+	return flushed
+}
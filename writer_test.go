@@ -0,0 +1,87 @@
+package orderedmap_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/abusizhishen/orderedmap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedMap_BufferedWriter(t *testing.T) {
+	t.Run("Order", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		w := m.BufferedWriter(4)
+		w.Set("foo", 1)
+		w.Set("bar", 2)
+		w.Delete("foo")
+		w.Set("baz", 3)
+		w.Set("foo", 4)
+		w.Flush()
+		assert.Equal(t, []interface{}{"bar", "baz", "foo"}, m.Keys())
+		assert.Equal(t, 4, m.GetOrDefault("foo", nil))
+
+		w.Delete("bar")
+		w.Close()
+		assert.Equal(t, []interface{}{"baz", "foo"}, m.Keys())
+	})
+
+	t.Run("Unbuffered", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		w := m.BufferedWriter(0)
+		w.Set("foo", 1)
+		w.Close()
+		assert.Equal(t, []interface{}{"foo"}, m.Keys())
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		w := m.BufferedWriter(16)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					w.Set(fmt.Sprintf("%d-%d", i, j), j)
+				}
+				w.Flush()
+			}(i)
+		}
+
+		wg.Wait()
+		w.Close()
+		assert.Equal(t, 800, m.Len())
+	})
+
+	t.Run("NegativeSize", func(t *testing.T) {
+		assert.Panics(t, func() {
+			orderedmap.NewOrderedMap().BufferedWriter(-1)
+		})
+	})
+
+	t.Run("NonStringKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap(orderedmap.WithStringKeysOnly())
+		w := m.BufferedWriter(1)
+		defer w.Close()
+		assert.Panics(t, func() {
+			w.Set(1, "foo")
+		})
+	})
+
+	t.Run("UnhashableKey", func(t *testing.T) {
+		m := orderedmap.NewOrderedMap()
+		w := m.BufferedWriter(1)
+		defer w.Close()
+		assert.Panics(t, func() {
+			w.Set([]int{1}, "foo")
+		})
+		assert.Panics(t, func() {
+			w.Delete([]int{1})
+		})
+		w.Set("foo", "bar")
+		w.Flush()
+		assert.Equal(t, []interface{}{"foo"}, m.Keys())
+	})
+}